@@ -0,0 +1,101 @@
+package libio
+
+import "io"
+
+// ahoCorasick is a BytesReplacer that finds the leftmost match among many literal
+// search tokens in a single pass over the buffer. On a tie the token added first
+// wins, the same as replacer.
+type ahoCorasick struct {
+	delta    [][256]int32 // goto function with failure transitions resolved
+	out      [][]int      // tokens ending at each state
+	searches [][]byte
+	replaces [][]byte
+
+	maxSearchLen  int
+	maxReplaceLen int
+	minRatio      float64
+}
+
+func newAhoCorasick(searches, replaces [][]byte) *ahoCorasick {
+	ac := &ahoCorasick{
+		delta:    make([][256]int32, 1),
+		out:      make([][]int, 1),
+		searches: searches,
+		replaces: replaces,
+		minRatio: -1,
+	}
+
+	for i, search := range searches {
+		state := int32(0)
+		for _, c := range search {
+			if ac.delta[state][c] == 0 {
+				ac.delta = append(ac.delta, [256]int32{})
+				ac.out = append(ac.out, nil)
+				ac.delta[state][c] = int32(len(ac.delta) - 1)
+			}
+			state = ac.delta[state][c]
+		}
+		ac.out[state] = append(ac.out[state], i)
+
+		searchLen, replaceLen, ratio := (&byteReplace{search: search, replace: replaces[i]}).GetSizingHints()
+		ac.maxSearchLen = max(ac.maxSearchLen, searchLen)
+		ac.maxReplaceLen = max(ac.maxReplaceLen, replaceLen)
+		if ratio > 0 && (ac.minRatio < 0 || ratio < ac.minRatio) {
+			ac.minRatio = ratio
+		}
+	}
+
+	// breadth first, resolving failure links into the goto function.
+	fail := make([]int32, len(ac.delta))
+	queue := make([]int32, 0, len(ac.delta))
+	for c := 0; c < 256; c++ {
+		if next := ac.delta[0][c]; next != 0 {
+			queue = append(queue, next)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		ac.out[state] = append(ac.out[state], ac.out[fail[state]]...)
+		for c := 0; c < 256; c++ {
+			next := ac.delta[state][c]
+			if next == 0 {
+				ac.delta[state][c] = ac.delta[fail[state]][c]
+				continue
+			}
+			fail[next] = ac.delta[fail[state]][c]
+			queue = append(queue, next)
+		}
+	}
+	return ac
+}
+
+func (ac *ahoCorasick) GetSizingHints() (int, int, float64) {
+	return ac.maxSearchLen, ac.maxReplaceLen, ac.minRatio
+}
+
+func (ac *ahoCorasick) Index(buf []byte) (int, []byte, []byte) {
+	resIndex, resToken := -1, -1
+	state := int32(0)
+	for i, c := range buf {
+		// no match ending from here on can start before the one found.
+		if resIndex >= 0 && i-ac.maxSearchLen+1 > resIndex {
+			break
+		}
+		state = ac.delta[state][c]
+		for _, token := range ac.out[state] {
+			index := i - len(ac.searches[token]) + 1
+			if resIndex == -1 || index < resIndex || (index == resIndex && token < resToken) {
+				resIndex, resToken = index, token
+			}
+		}
+	}
+	if resIndex < 0 {
+		return -1, nil, nil
+	}
+	return resIndex, ac.searches[resToken], ac.replaces[resToken]
+}
+
+func (ac *ahoCorasick) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, ac)
+}
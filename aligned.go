@@ -0,0 +1,48 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool/ladder"
+	"io"
+)
+
+// NewAlignedReader returns a reader of src that only ever reads src into a buffer
+// whose length is a multiple of alignment, as required by devices reading whole
+// sectors, whatever the size of the buffers given to its Read. The bytes read are
+// kept in a buffer from the ladder pool, given back at the end of the stream.
+func NewAlignedReader(src io.Reader, alignment int) io.Reader {
+	if alignment <= 0 {
+		panic("libio.NewAlignedReader: alignment must be positive")
+	}
+	return &alignedReader{r: src, size: max(alignment, defaultBufSize/alignment*alignment)}
+}
+
+type alignedReader struct {
+	r    io.Reader
+	size int    // multiple of the alignment
+	buf  []byte // buf[off:n] is read but not delivered yet
+	off  int
+	n    int
+	err  error
+}
+
+func (a *alignedReader) Read(p []byte) (int, error) {
+	for a.off == a.n {
+		if a.err != nil {
+			if a.buf != nil {
+				_ = ladder.Put(a.buf)
+				a.buf = nil
+			}
+			return 0, a.err
+		}
+		if a.buf == nil {
+			if a.buf = ladder.Get(a.size); a.buf == nil {
+				a.buf = make([]byte, a.size)
+			}
+		}
+		a.off = 0
+		a.n, a.err = a.r.Read(a.buf[:a.size])
+	}
+	n := copy(p, a.buf[a.off:a.n])
+	a.off += n
+	return n, nil
+}
@@ -0,0 +1,30 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type sizeCheckingReader struct {
+	r         io.Reader
+	alignment int
+	t         *testing.T
+}
+
+func (s sizeCheckingReader) Read(p []byte) (int, error) {
+	if len(p)%s.alignment != 0 {
+		s.t.Errorf("read of %d bytes is not aligned on %d", len(p), s.alignment)
+	}
+	return s.r.Read(p)
+}
+
+func TestAlignedReader(t *testing.T) {
+	src := strings.Repeat("0123456789", 1000)
+	r := NewAlignedReader(sizeCheckingReader{strings.NewReader(src), 512, t}, 512)
+	res, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil || string(res) != src {
+		t.Errorf("should read the source back, got %d bytes %v", len(res), err)
+	}
+}
@@ -0,0 +1,60 @@
+package libio
+
+import "io"
+
+// ansiMaxSequenceLen bounds the sequences NewANSIStripReader holds back, real ones
+// are a few bytes long.
+const ansiMaxSequenceLen = 64
+
+// NewANSIStripReader returns a reader of src without the ANSI CSI escape sequences,
+// those matching \x1b\[[0-9;]*[A-Za-z], e.g. color codes in terminal output. An
+// incomplete sequence, or one longer than 64 bytes, is kept as is.
+func NewANSIStripReader(src io.Reader) io.Reader {
+	// pending is the beginning of a sequence: ESC, then '[' and parameters.
+	var pending []byte
+	step := func(dst, p []byte) []byte {
+		for _, c := range p {
+			switch {
+			case len(pending) == 0:
+				if c == 0x1b {
+					pending = append(pending, c)
+					continue
+				}
+			case len(pending) == 1:
+				if c == '[' {
+					pending = append(pending, c)
+					continue
+				}
+				dst = append(dst, pending...)
+				pending = pending[:0]
+				if c == 0x1b {
+					pending = append(pending, c)
+					continue
+				}
+			default:
+				if ('0' <= c && c <= '9' || c == ';') && len(pending) < ansiMaxSequenceLen-1 {
+					pending = append(pending, c)
+					continue
+				}
+				if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' {
+					pending = pending[:0]
+					continue
+				}
+				dst = append(dst, pending...)
+				pending = pending[:0]
+				if c == 0x1b {
+					pending = append(pending, c)
+					continue
+				}
+			}
+			dst = append(dst, c)
+		}
+		return dst
+	}
+	flush := func(dst []byte) []byte {
+		dst = append(dst, pending...)
+		pending = pending[:0]
+		return dst
+	}
+	return newTransformReader(src, step, flush)
+}
@@ -0,0 +1,27 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestANSIStripReader(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{"\x1b[1;31mred\x1b[0m plain", "red plain"},
+		{"\x1b[Kclear\x1b\x1b[2Jx", "clear\x1bx"},
+		{"no \x1b(B csi \x1b[1;2", "no \x1b(B csi \x1b[1;2"},
+		{"\x1b[12!x", "\x1b[12!x"},
+		{"\x1b[" + strings.Repeat("1", 100) + "m", "\x1b[" + strings.Repeat("1", 100) + "m"},
+	}
+	for _, c := range cases {
+		res, err := io.ReadAll(NewANSIStripReader(iotest.OneByteReader(strings.NewReader(c.src))))
+		if err != nil || string(res) != c.want {
+			t.Errorf("should %q but %q %v", c.want, res, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package libio
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// AtomicReplacer is a Replacer whose rules can be swapped at runtime without
+// locking the read path. Streams that are already being replaced keep using the
+// replacer that was current when Replace was called.
+type AtomicReplacer struct {
+	v atomic.Value
+}
+
+// replacerHolder keeps the stored concrete type constant for atomic.Value.
+type replacerHolder struct {
+	Replacer
+}
+
+func NewAtomicReplacer(r Replacer) *AtomicReplacer {
+	res := &AtomicReplacer{}
+	res.Store(r)
+	return res
+}
+
+// Store atomically replaces the current replacer.
+func (a *AtomicReplacer) Store(r Replacer) {
+	a.v.Store(replacerHolder{r})
+}
+
+// Load returns the current replacer, nil if none has been stored.
+func (a *AtomicReplacer) Load() Replacer {
+	h, _ := a.v.Load().(replacerHolder)
+	return h.Replacer
+}
+
+// Replace delegates to the current replacer. src is returned unchanged if no
+// replacer has been stored.
+func (a *AtomicReplacer) Replace(src io.Reader) io.Reader {
+	r := a.Load()
+	if r == nil {
+		return src
+	}
+	return r.Replace(src)
+}
@@ -0,0 +1,27 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestAtomicReplacer(t *testing.T) {
+	var a AtomicReplacer
+	res, _ := io.ReadAll(a.Replace(strings.NewReader("foo")))
+	if string(res) != "foo" {
+		t.Errorf("empty replacer should pass through, got %s", res)
+	}
+
+	a.Store(NewReplacer("foo", "bar"))
+	res, _ = io.ReadAll(a.Replace(strings.NewReader("foo")))
+	if string(res) != "bar" {
+		t.Errorf("should bar but %s", res)
+	}
+
+	a.Store(NewReplacer("foo", "baz"))
+	res, _ = io.ReadAll(a.Replace(strings.NewReader("foo")))
+	if string(res) != "baz" {
+		t.Errorf("should baz but %s", res)
+	}
+}
@@ -0,0 +1,56 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool"
+	"io"
+)
+
+type blockTransformReader struct {
+	r         io.Reader
+	blockSize int
+	fn        func([]byte) []byte
+	pool      bytespool.BytesPool
+	block     []byte
+	out       []byte // transformed bytes not delivered yet
+	err       error
+}
+
+// NewBlockTransformReader returns a reader of src that reads it in blocks of
+// blockSize bytes and delivers fn(block) for each of them; the last block may be
+// shorter. The block buffer is taken from pool and reused for every block, so fn
+// may transform it in place and return it, but must not keep it. The buffer is
+// given back to pool once src is exhausted.
+func NewBlockTransformReader(src io.Reader, blockSize int, fn func([]byte) []byte, pool bytespool.BytesPool) io.Reader {
+	if blockSize <= 0 {
+		panic("libio.NewBlockTransformReader: block size must be positive")
+	}
+	return &blockTransformReader{r: src, blockSize: blockSize, fn: fn, pool: pool}
+}
+
+func (b *blockTransformReader) Read(p []byte) (int, error) {
+	for len(b.out) == 0 {
+		if b.err != nil {
+			if b.block != nil {
+				_ = b.pool.Put(b.block)
+				b.block = nil
+			}
+			return 0, b.err
+		}
+		if b.block == nil {
+			if b.block = b.pool.Get(b.blockSize); b.block == nil {
+				b.block = make([]byte, b.blockSize)
+			}
+		}
+		n, err := io.ReadFull(b.r, b.block[:b.blockSize])
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		b.err = err
+		if n > 0 {
+			b.out = b.fn(b.block[:n])
+		}
+	}
+	n := copy(p, b.out)
+	b.out = b.out[n:]
+	return n, nil
+}
@@ -0,0 +1,29 @@
+package libio
+
+import (
+	"bytes"
+	"github.com/eleztian/pipe/bytespool/fixed"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBlockTransformReader(t *testing.T) {
+	pool := fixed.NewBytePool(1, 4)
+	var blocks []string
+	r := NewBlockTransformReader(iotest.HalfReader(strings.NewReader("abcdefghij")), 4, func(b []byte) []byte {
+		blocks = append(blocks, string(b))
+		return append(bytes.ToUpper(b), '|')
+	}, pool)
+	res, err := io.ReadAll(r)
+	if err != nil || string(res) != "ABCD|EFGH|IJ|" {
+		t.Fatalf("unexpected result %s %v", res, err)
+	}
+	if strings.Join(blocks, ",") != "abcd,efgh,ij" {
+		t.Errorf("unexpected blocks %v", blocks)
+	}
+	if pool.Len() != 1 {
+		t.Errorf("block buffer is not put back")
+	}
+}
@@ -0,0 +1,56 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+)
+
+// Encoding is a Unicode encoding detected from a byte order mark.
+type Encoding int
+
+const (
+	BOMUnknown Encoding = iota // no byte order mark
+	BOMUTF8
+	BOMUTF16LE
+	BOMUTF16BE
+	BOMUTF32LE
+	BOMUTF32BE
+)
+
+// boms is checked in order, UTF-32LE goes before UTF-16LE whose mark it starts with.
+var boms = []struct {
+	enc  Encoding
+	mark []byte
+}{
+	{BOMUTF32LE, []byte{0xFF, 0xFE, 0x00, 0x00}},
+	{BOMUTF32BE, []byte{0x00, 0x00, 0xFE, 0xFF}},
+	{BOMUTF8, []byte{0xEF, 0xBB, 0xBF}},
+	{BOMUTF16LE, []byte{0xFF, 0xFE}},
+	{BOMUTF16BE, []byte{0xFE, 0xFF}},
+}
+
+// NewBOMDetectingReader reads the first 4 bytes of src to detect a byte order mark
+// and returns a reader of src without it, along with the detected encoding. If
+// there is no mark the encoding is BOMUnknown and nothing is stripped. The error
+// is the one returned by src while reading the first bytes, if not io.EOF.
+func NewBOMDetectingReader(src io.Reader) (io.Reader, Encoding, error) {
+	head := make([]byte, 4)
+	n, err := io.ReadFull(src, head)
+	head = head[:n]
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+
+	enc := BOMUnknown
+	for _, b := range boms {
+		if bytes.HasPrefix(head, b.mark) {
+			enc = b.enc
+			head = head[len(b.mark):]
+			break
+		}
+	}
+	if err != nil {
+		return bytes.NewReader(head), enc, err
+	}
+	return io.MultiReader(bytes.NewReader(head), src), enc, nil
+}
@@ -0,0 +1,34 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewBOMDetectingReader(t *testing.T) {
+	cases := []struct {
+		src  string
+		enc  Encoding
+		rest string
+	}{
+		{"\xEF\xBB\xBFhello", BOMUTF8, "hello"},
+		{"\xFF\xFEh\x00", BOMUTF16LE, "h\x00"},
+		{"\xFE\xFF\x00h", BOMUTF16BE, "\x00h"},
+		{"\xFF\xFE\x00\x00h\x00\x00\x00", BOMUTF32LE, "h\x00\x00\x00"},
+		{"\x00\x00\xFE\xFF\x00\x00\x00h", BOMUTF32BE, "\x00\x00\x00h"},
+		{"hello", BOMUnknown, "hello"},
+		{"h", BOMUnknown, "h"},
+		{"", BOMUnknown, ""},
+	}
+	for _, c := range cases {
+		r, enc, err := NewBOMDetectingReader(strings.NewReader(c.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, _ := io.ReadAll(r)
+		if enc != c.enc || string(res) != c.rest {
+			t.Errorf("%q: should %d %q but %d %q", c.src, c.enc, c.rest, enc, res)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool"
+	"io"
+	"sync"
+)
+
+type pipeChunk struct {
+	buf []byte // chunkSize bytes from the pool
+	n   int
+}
+
+// bufferedPipe is the state shared by both ends of a buffered pipe.
+type bufferedPipe struct {
+	pool      bytespool.BytesPool
+	chunkSize int
+	ch        chan pipeChunk
+	wclosed   chan struct{}
+	rclosed   chan struct{}
+	wonce     sync.Once
+	ronce     sync.Once
+}
+
+// BufferedPipeReader is the read half of a buffered pipe.
+type BufferedPipeReader struct {
+	p   *bufferedPipe
+	mu  sync.Mutex // guards cur against Close, not held while blocking
+	cur pipeChunk  // cur.buf[off:cur.n] is left to read
+	off int
+}
+
+// BufferedPipeWriter is the write half of a buffered pipe.
+type BufferedPipeWriter struct {
+	p *bufferedPipe
+}
+
+// NewBufferedPipe is like io.Pipe but lets up to maxChunks chunks of chunkSize
+// bytes be written ahead of the reader before Write blocks. The chunks are taken
+// from pool and given back once read, so the memory can be reused across pipes.
+// Each half is meant to be used from its own goroutine.
+func NewBufferedPipe(pool bytespool.BytesPool, chunkSize, maxChunks int) (*BufferedPipeReader, *BufferedPipeWriter) {
+	if chunkSize <= 0 || maxChunks <= 0 {
+		panic("libio.NewBufferedPipe: chunk size and count must be positive")
+	}
+	p := &bufferedPipe{
+		pool:      pool,
+		chunkSize: chunkSize,
+		ch:        make(chan pipeChunk, maxChunks),
+		wclosed:   make(chan struct{}),
+		rclosed:   make(chan struct{}),
+	}
+	return &BufferedPipeReader{p: p}, &BufferedPipeWriter{p: p}
+}
+
+func (p *bufferedPipe) put(c pipeChunk) {
+	if c.buf != nil {
+		_ = p.pool.Put(c.buf)
+	}
+}
+
+// Read reads data written to the pipe, blocking until some is available. It
+// returns io.EOF once the writer is closed and everything written was read, and
+// io.ErrClosedPipe after Close.
+func (r *BufferedPipeReader) Read(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.off == r.cur.n || r.p.readClosed() {
+		r.p.put(r.cur)
+		r.cur, r.off = pipeChunk{}, 0
+		if r.p.readClosed() {
+			return 0, io.ErrClosedPipe
+		}
+		r.mu.Unlock()
+		c, err := r.p.next()
+		r.mu.Lock()
+		if err != nil {
+			return 0, err
+		}
+		// if Close ran meanwhile the loop gives c back.
+		r.cur = c
+	}
+	n := copy(b, r.cur.buf[r.off:r.cur.n])
+	r.off += n
+	return n, nil
+}
+
+// next waits for the next chunk written to the pipe.
+func (p *bufferedPipe) next() (pipeChunk, error) {
+	select {
+	case c := <-p.ch:
+		return c, nil
+	case <-p.rclosed:
+		return pipeChunk{}, io.ErrClosedPipe
+	case <-p.wclosed:
+		// the chunks written before Close are still to be read.
+		select {
+		case c := <-p.ch:
+			return c, nil
+		default:
+			return pipeChunk{}, io.EOF
+		}
+	}
+}
+
+func (p *bufferedPipe) readClosed() bool {
+	select {
+	case <-p.rclosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain gives the queued chunks back to the pool.
+func (p *bufferedPipe) drain() {
+	for {
+		select {
+		case c := <-p.ch:
+			p.put(c)
+		default:
+			return
+		}
+	}
+}
+
+// Close makes the pending and later Reads and Writes fail with io.ErrClosedPipe
+// and gives the queued chunks back to the pool.
+func (r *BufferedPipeReader) Close() error {
+	r.p.ronce.Do(func() { close(r.p.rclosed) })
+	r.mu.Lock()
+	r.p.put(r.cur)
+	r.cur, r.off = pipeChunk{}, 0
+	r.mu.Unlock()
+	r.p.drain()
+	return nil
+}
+
+// Write copies b into chunks queued for the reader, blocking while maxChunks are
+// queued already. It fails with io.ErrClosedPipe if either half is closed.
+func (w *BufferedPipeWriter) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		select {
+		case <-w.p.wclosed:
+			return written, io.ErrClosedPipe
+		case <-w.p.rclosed:
+			return written, io.ErrClosedPipe
+		default:
+		}
+		buf := w.p.pool.Get(w.p.chunkSize)
+		if buf == nil {
+			buf = make([]byte, w.p.chunkSize)
+		}
+		c := pipeChunk{buf: buf, n: copy(buf, b[written:])}
+		select {
+		case w.p.ch <- c:
+			if w.p.readClosed() {
+				// the reader may have closed after its last drain.
+				w.p.drain()
+				return written, io.ErrClosedPipe
+			}
+			written += c.n
+		case <-w.p.rclosed:
+			w.p.put(c)
+			return written, io.ErrClosedPipe
+		}
+	}
+	return written, nil
+}
+
+// Close makes the reader return io.EOF once it has read the chunks written so far.
+func (w *BufferedPipeWriter) Close() error {
+	w.p.wonce.Do(func() { close(w.p.wclosed) })
+	return nil
+}
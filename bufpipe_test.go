@@ -0,0 +1,79 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBufferedPipe(t *testing.T) {
+	pool := bytespool.NewCountingPool(bytespool.NewAutoPool([]int{16}, []int{4}))
+	r, w := NewBufferedPipe(pool, 16, 4)
+
+	// 4 chunks fit without a reader.
+	if n, err := w.Write([]byte(strings.Repeat("x", 64))); n != 64 || err != nil {
+		t.Fatalf("should buffer 64 bytes but %d %v", n, err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = w.Write([]byte("y"))
+		_ = w.Close()
+	}()
+	select {
+	case <-done:
+		t.Fatalf("Write should block while maxChunks are queued")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	res, err := io.ReadAll(r)
+	<-done
+	if want := strings.Repeat("x", 64) + "y"; err != nil || string(res) != want {
+		t.Errorf("should %q but %q %v", want, res, err)
+	}
+
+	r, w = NewBufferedPipe(pool, 16, 1)
+	_ = r.Close()
+	if _, err := w.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Errorf("should fail writing to a pipe closed by its reader but %v", err)
+	}
+}
+
+func TestBufferedPipe_CloseGivesChunksBack(t *testing.T) {
+	pool := bytespool.NewCountingPool(bytespool.NewAutoPool([]int{16}, []int{4}))
+	r, w := NewBufferedPipe(pool, 16, 4)
+	if _, err := w.Write([]byte(strings.Repeat("x", 40))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(make([]byte, 4)); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Close()
+	if n := pool.OutstandingBytes(); n != 0 {
+		t.Errorf("should give every chunk back but %d bytes are out", n)
+	}
+	if n, err := r.Read(make([]byte, 4)); n != 0 || err != io.ErrClosedPipe {
+		t.Errorf("should fail reading a closed pipe but %d %v", n, err)
+	}
+
+	// a Write racing with Close must give its chunk back too.
+	for i := 0; i < 100; i++ {
+		r, w := NewBufferedPipe(pool, 16, 4)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = r.Close()
+		}()
+		for {
+			if _, err := w.Write([]byte("x")); err != nil {
+				break
+			}
+		}
+		<-done
+	}
+	if n := pool.OutstandingBytes(); n != 0 {
+		t.Errorf("should give every chunk back but %d bytes are out", n)
+	}
+}
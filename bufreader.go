@@ -0,0 +1,153 @@
+package libio
+
+import (
+	"errors"
+	"github.com/eleztian/pipe/bytespool"
+	"io"
+)
+
+// PooledBufReader is a buffered reader like bufio.Reader whose buffer is taken from
+// a BytesPool and given back by Close.
+type PooledBufReader struct {
+	r        io.Reader
+	pool     bytespool.BytesPool
+	buf      []byte
+	r0, r1   int // buf[r0:r1] is buffered
+	lastByte int // last byte read for UnreadByte, -1 if invalid
+	err      error
+}
+
+// NewPooledBufReader returns a reader of r buffering up to size bytes in a buffer
+// of pool. Close gives the buffer back.
+func NewPooledBufReader(r io.Reader, pool bytespool.BytesPool, size int) *PooledBufReader {
+	if size <= 0 {
+		panic("libio.NewPooledBufReader: size must be positive")
+	}
+	buf := pool.Get(size)
+	if buf == nil {
+		buf = make([]byte, size)
+	}
+	return &PooledBufReader{r: r, pool: pool, buf: buf, lastByte: -1}
+}
+
+// Buffered returns the number of bytes that can be read from the buffer.
+func (b *PooledBufReader) Buffered() int {
+	return b.r1 - b.r0
+}
+
+// fill reads once into the free space of the buffer.
+func (b *PooledBufReader) fill() {
+	if b.r0 > 0 {
+		copy(b.buf, b.buf[b.r0:b.r1])
+		b.r1 -= b.r0
+		b.r0 = 0
+	}
+	n, err := b.r.Read(b.buf[b.r1:])
+	b.r1 += n
+	b.err = err
+}
+
+func (b *PooledBufReader) readErr() error {
+	err := b.err
+	if err != errBufClosed {
+		b.err = nil
+	}
+	return err
+}
+
+func (b *PooledBufReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		if b.Buffered() > 0 {
+			return 0, nil
+		}
+		return 0, b.readErr()
+	}
+	if b.r0 == b.r1 {
+		if b.err != nil {
+			return 0, b.readErr()
+		}
+		if len(p) >= len(b.buf) {
+			// large read with an empty buffer, read directly.
+			n, err := b.r.Read(p)
+			if n > 0 {
+				b.lastByte = int(p[n-1])
+			}
+			return n, err
+		}
+		b.r0, b.r1 = 0, 0
+		b.fill()
+		if b.r0 == b.r1 {
+			return 0, b.readErr()
+		}
+	}
+	n := copy(p, b.buf[b.r0:b.r1])
+	b.r0 += n
+	b.lastByte = int(b.buf[b.r0-1])
+	return n, nil
+}
+
+func (b *PooledBufReader) ReadByte() (byte, error) {
+	for b.r0 == b.r1 {
+		if b.err != nil {
+			return 0, b.readErr()
+		}
+		b.fill()
+	}
+	c := b.buf[b.r0]
+	b.r0++
+	b.lastByte = int(c)
+	return c, nil
+}
+
+// UnreadByte unreads the last byte read. Only the most recently read byte can be
+// unread.
+func (b *PooledBufReader) UnreadByte() error {
+	if b.lastByte < 0 || b.buf == nil || b.r0 == 0 && b.r1 > 0 {
+		return errors.New("libio.PooledBufReader: invalid use of UnreadByte")
+	}
+	if b.r0 > 0 {
+		b.r0--
+	} else {
+		// the buffer is empty, as after a direct read.
+		b.r1 = 1
+	}
+	b.buf[b.r0] = byte(b.lastByte)
+	b.lastByte = -1
+	return nil
+}
+
+// WriteTo writes the buffered data and then the rest of the underlying reader to w.
+func (b *PooledBufReader) WriteTo(w io.Writer) (int64, error) {
+	b.lastByte = -1
+	var total int64
+	for {
+		if b.r0 < b.r1 {
+			n, err := w.Write(b.buf[b.r0:b.r1])
+			b.r0 += n
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
+		}
+		if b.err != nil {
+			err := b.readErr()
+			if err == io.EOF {
+				err = nil
+			}
+			return total, err
+		}
+		b.fill()
+	}
+}
+
+// Close gives the buffer back to the pool. It does not close the underlying reader.
+func (b *PooledBufReader) Close() error {
+	if b.buf == nil {
+		return nil
+	}
+	_ = b.pool.Put(b.buf)
+	b.buf = nil
+	b.r0, b.r1 = 0, 0
+	b.err = errBufClosed
+	return nil
+}
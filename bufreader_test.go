@@ -0,0 +1,45 @@
+package libio
+
+import (
+	"bytes"
+	"github.com/eleztian/pipe/bytespool/fixed"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPooledBufReader(t *testing.T) {
+	pool := fixed.NewBytePool(1, 4)
+	r := NewPooledBufReader(iotest.HalfReader(strings.NewReader("abcdefghij")), pool, 4)
+
+	c, err := r.ReadByte()
+	if err != nil || c != 'a' {
+		t.Fatalf("unexpected byte %c %v", c, err)
+	}
+	if err = r.UnreadByte(); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.UnreadByte(); err == nil {
+		t.Errorf("should not unread twice")
+	}
+	p := make([]byte, 3)
+	if _, err = io.ReadFull(r, p); err != nil || string(p) != "abc" {
+		t.Fatalf("unexpected read %s %v", p, err)
+	}
+	var dst bytes.Buffer
+	if _, err = r.WriteTo(&dst); err != nil || dst.String() != "defghij" {
+		t.Fatalf("unexpected WriteTo %s %v", dst.String(), err)
+	}
+	if _, err = r.ReadByte(); err != io.EOF {
+		t.Errorf("should EOF but %v", err)
+	}
+
+	r.Close()
+	if pool.Len() != 1 {
+		t.Errorf("buffer is not put back")
+	}
+	if _, err = r.Read(p); err == nil {
+		t.Errorf("should fail after Close")
+	}
+}
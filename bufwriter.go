@@ -0,0 +1,157 @@
+package libio
+
+import (
+	"errors"
+	"github.com/eleztian/pipe/bytespool"
+	"io"
+)
+
+var errBufClosed = errors.New("libio: use of closed buffer")
+
+// PooledBufWriter is a buffered writer like bufio.Writer whose buffer is taken from
+// a BytesPool and given back by Close. It implements io.ReaderFrom rather than
+// io.WriterTo, which does not apply to a writer.
+type PooledBufWriter struct {
+	w    io.Writer
+	pool bytespool.BytesPool
+	buf  []byte
+	n    int // buf[:n] is buffered
+	err  error
+}
+
+// NewPooledBufWriter returns a writer to w buffering up to size bytes in a buffer
+// of pool. Close must be called to flush and give the buffer back.
+func NewPooledBufWriter(w io.Writer, pool bytespool.BytesPool, size int) *PooledBufWriter {
+	if size <= 0 {
+		panic("libio.NewPooledBufWriter: size must be positive")
+	}
+	buf := pool.Get(size)
+	if buf == nil {
+		buf = make([]byte, size)
+	}
+	return &PooledBufWriter{w: w, pool: pool, buf: buf}
+}
+
+// Flush writes the buffered data to the underlying writer.
+func (b *PooledBufWriter) Flush() error {
+	if b.err != nil {
+		return b.err
+	}
+	if b.n == 0 {
+		return nil
+	}
+	n, err := b.w.Write(b.buf[:b.n])
+	if n < b.n && err == nil {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		if n > 0 && n < b.n {
+			copy(b.buf, b.buf[n:b.n])
+		}
+		b.n -= n
+		b.err = err
+		return err
+	}
+	b.n = 0
+	return nil
+}
+
+// Available returns how many bytes can be written before the buffer is flushed.
+func (b *PooledBufWriter) Available() int {
+	return len(b.buf) - b.n
+}
+
+// Buffered returns the number of bytes written into the buffer.
+func (b *PooledBufWriter) Buffered() int {
+	return b.n
+}
+
+func (b *PooledBufWriter) Write(p []byte) (int, error) {
+	nn := 0
+	for len(p) > b.Available() && b.err == nil {
+		var n int
+		if b.n == 0 {
+			// large write with an empty buffer, write directly.
+			n, b.err = b.w.Write(p)
+		} else {
+			n = copy(b.buf[b.n:], p)
+			b.n += n
+			_ = b.Flush()
+		}
+		nn += n
+		p = p[n:]
+	}
+	if b.err != nil {
+		return nn, b.err
+	}
+	n := copy(b.buf[b.n:], p)
+	b.n += n
+	return nn + n, nil
+}
+
+func (b *PooledBufWriter) WriteByte(c byte) error {
+	if b.err != nil {
+		return b.err
+	}
+	if b.Available() <= 0 && b.Flush() != nil {
+		return b.err
+	}
+	b.buf[b.n] = c
+	b.n++
+	return nil
+}
+
+func (b *PooledBufWriter) WriteString(s string) (int, error) {
+	nn := 0
+	for len(s) > b.Available() && b.err == nil {
+		n := copy(b.buf[b.n:], s)
+		b.n += n
+		nn += n
+		s = s[n:]
+		_ = b.Flush()
+	}
+	if b.err != nil {
+		return nn, b.err
+	}
+	n := copy(b.buf[b.n:], s)
+	b.n += n
+	return nn + n, nil
+}
+
+// ReadFrom reads r until EOF into the buffer, flushing it as it fills up.
+func (b *PooledBufWriter) ReadFrom(r io.Reader) (int64, error) {
+	var total int64
+	for b.err == nil {
+		if b.Available() == 0 {
+			if err := b.Flush(); err != nil {
+				return total, err
+			}
+		}
+		n, err := r.Read(b.buf[b.n:])
+		b.n += n
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, b.err
+}
+
+// Close flushes the buffer and gives it back to the pool. It does not close the
+// underlying writer.
+func (b *PooledBufWriter) Close() error {
+	if b.buf == nil {
+		return nil
+	}
+	err := b.Flush()
+	_ = b.pool.Put(b.buf)
+	b.buf = nil
+	b.n = 0
+	if b.err == nil {
+		b.err = errBufClosed
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package libio
+
+import (
+	"bytes"
+	"github.com/eleztian/pipe/bytespool/fixed"
+	"strings"
+	"testing"
+)
+
+func TestPooledBufWriter(t *testing.T) {
+	pool := fixed.NewBytePool(1, 4)
+	var dst bytes.Buffer
+	w := NewPooledBufWriter(&dst, pool, 4)
+	w.WriteString("ab")
+	w.WriteByte('c')
+	w.Write([]byte("defghij"))
+	w.ReadFrom(strings.NewReader("klm"))
+	if dst.Len() > 10 {
+		t.Fatalf("should buffer the tail but wrote %q", dst.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if dst.String() != "abcdefghijklm" {
+		t.Errorf("should abcdefghijklm but %s", dst.String())
+	}
+	if pool.Len() != 1 {
+		t.Errorf("buffer is not put back")
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Errorf("should fail after Close")
+	}
+
+	w = NewPooledBufWriter(errWriter{}, pool, 4)
+	w.WriteString("abcdef")
+	if err := w.Close(); err == nil {
+		t.Errorf("should return the write error")
+	}
+}
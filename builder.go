@@ -0,0 +1,154 @@
+package libio
+
+import (
+	"errors"
+	"io"
+)
+
+// literal rule count from which ReplacerBuilder switches to a single
+// Aho-Corasick automaton instead of scanning the buffer once per rule.
+const ahoCorasickMinRules = 4
+
+// ReplacerBuilder builds a Replacer from a mix of literal, case-insensitive and
+// regex rules. Rules are matched leftmost first; on a tie the rule added first
+// wins.
+type ReplacerBuilder struct {
+	rules    []BytesReplacer
+	literals int
+	limit    int
+	err      error
+}
+
+func NewReplacerBuilder() *ReplacerBuilder {
+	return &ReplacerBuilder{}
+}
+
+// AddLiteral adds a rule replacing search with replace.
+func (b *ReplacerBuilder) AddLiteral(search, replace string) *ReplacerBuilder {
+	if search == "" {
+		b.setErr(errors.New("libio.ReplacerBuilder: search token cannot be empty"))
+		return b
+	}
+	b.rules = append(b.rules, &byteReplace{search: []byte(search), replace: []byte(replace)})
+	b.literals++
+	return b
+}
+
+// AddCaseInsensitive adds a rule replacing search, compared under case-folding,
+// with replace.
+func (b *ReplacerBuilder) AddCaseInsensitive(search, replace string) *ReplacerBuilder {
+	if search == "" {
+		b.setErr(errors.New("libio.ReplacerBuilder: search token cannot be empty"))
+		return b
+	}
+	b.rules = append(b.rules, &foldReplace{search: []byte(search), replace: []byte(replace)})
+	return b
+}
+
+// AddRegex adds a rule replacing every match of pattern with the literal replace.
+// The pattern must not match the empty string and its match length must be
+// bounded, e.g. "[0-9]{1,16}" rather than "[0-9]+".
+func (b *ReplacerBuilder) AddRegex(pattern, replace string) *ReplacerBuilder {
+	rr, err := newRegexReplace(pattern, replace)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.rules = append(b.rules, rr)
+	return b
+}
+
+// WithLimit stops replacing after n replacements per stream. n <= 0 means no limit.
+func (b *ReplacerBuilder) WithLimit(n int) *ReplacerBuilder {
+	b.limit = n
+	return b
+}
+
+// Build returns the Replacer for the rules added so far, or the first error
+// encountered while adding them.
+func (b *ReplacerBuilder) Build() (Replacer, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.rules) == 0 {
+		return nil, errors.New("libio.ReplacerBuilder: no replace rules")
+	}
+
+	var res BytesReplacer
+	if b.literals == len(b.rules) && b.literals >= ahoCorasickMinRules {
+		searches := make([][]byte, len(b.rules))
+		replaces := make([][]byte, len(b.rules))
+		for i, rule := range b.rules {
+			br := rule.(*byteReplace)
+			searches[i], replaces[i] = br.search, br.replace
+		}
+		res = newAhoCorasick(searches, replaces)
+	} else {
+		r := &replacer{minRatio: -1}
+		for _, rule := range b.rules {
+			r.add(rule)
+		}
+		res = r
+	}
+
+	if b.limit > 0 {
+		return &limitReplacer{inner: res, limit: b.limit}, nil
+	}
+	return res.(Replacer), nil
+}
+
+func (b *ReplacerBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// limitReplacer stops replacing after limit replacements per stream.
+type limitReplacer struct {
+	inner BytesReplacer
+	limit int
+}
+
+func (l *limitReplacer) newBytesReplacer() BytesReplacer {
+	return &limitIndex{BytesReplacer: l.inner, remaining: l.limit}
+}
+
+func (l *limitReplacer) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, l.newBytesReplacer())
+}
+
+// limitIndex holds the per stream count of a limitReplacer.
+type limitIndex struct {
+	BytesReplacer
+	remaining int
+}
+
+func (l *limitIndex) Index(buf []byte) (int, []byte, []byte) {
+	if l.remaining <= 0 {
+		return -1, nil, nil
+	}
+	return l.BytesReplacer.Index(buf)
+}
+
+func (l *limitIndex) SetOffset(offset int64) {
+	if os, ok := l.BytesReplacer.(OffsetSetter); ok {
+		os.SetOffset(offset)
+	}
+}
+
+func (l *limitIndex) Matched(offset int64, search, replace []byte) {
+	l.remaining--
+	if mo, ok := l.BytesReplacer.(MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+}
+
+func (l *limitIndex) clone() (BytesReplacer, bool) {
+	inner, ok := cloneBytesReplacer(l.BytesReplacer)
+	return &limitIndex{BytesReplacer: inner, remaining: l.remaining}, ok
+}
+
+func (l *limitIndex) stopAtMatch() bool {
+	s, ok := l.BytesReplacer.(stopper)
+	return ok && s.stopAtMatch()
+}
@@ -0,0 +1,85 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReplacerBuilder_AhoCorasick(t *testing.T) {
+	oldnews := []string{"zt", "zhangtian", "tzzzzzzzztzzzt", "zt2", "ab", "x", "abc", "y", "b", "zz"}
+	b := NewReplacerBuilder()
+	for i := 0; i < len(oldnews); i += 2 {
+		b.AddLiteral(oldnews[i], oldnews[i+1])
+	}
+	r, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.(*ahoCorasick); !ok {
+		t.Fatalf("literal rules should use aho-corasick, got %T", r)
+	}
+
+	content := "zt ztztzt2zzt abcab tzzzzzzzztzzzt bb zt ztztzt2ztztzzzt abcabc"
+	res, _ := io.ReadAll(r.Replace(strings.NewReader(content)))
+	expect := strings.NewReplacer(oldnews...).Replace(content)
+	if string(res) != expect {
+		t.Errorf("should %s but %s", expect, res)
+	}
+}
+
+func TestReplacerBuilder_Mixed(t *testing.T) {
+	r, err := NewReplacerBuilder().
+		AddLiteral("foo", "bar").
+		AddCaseInsensitive("hello", "bye").
+		AddRegex(`[0-9]{3}-[0-9]{4}`, "XXX-XXXX").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ := io.ReadAll(r.Replace(strings.NewReader("HeLLo foo, call 555-1234")))
+	if string(res) != "bye bar, call XXX-XXXX" {
+		t.Errorf("unexpected result %s", res)
+	}
+}
+
+func TestReplacerBuilder_Limit(t *testing.T) {
+	r, err := NewReplacerBuilder().AddLiteral("a", "b").WithLimit(2).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		res, _ := io.ReadAll(r.Replace(strings.NewReader("aaaa")))
+		if string(res) != "bbaa" {
+			t.Errorf("should bbaa but %s", res)
+		}
+	}
+}
+
+func TestReplacerBuilder_Errors(t *testing.T) {
+	if _, err := NewReplacerBuilder().Build(); err == nil {
+		t.Error("empty builder should fail")
+	}
+	if _, err := NewReplacerBuilder().AddLiteral("", "a").Build(); err == nil {
+		t.Error("empty search should fail")
+	}
+	if _, err := NewReplacerBuilder().AddRegex(`a+`, "b").Build(); err == nil {
+		t.Error("unbounded regex should fail")
+	}
+	if _, err := NewReplacerBuilder().AddRegex(`a?`, "b").Build(); err == nil {
+		t.Error("regex matching empty string should fail")
+	}
+}
+
+func TestReplacerBuilder_LimitAcrossReads(t *testing.T) {
+	r, err := NewReplacerBuilder().AddLiteral("ab", "x").AddLiteral("abcd", "y").WithLimit(3).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := strings.Repeat("ab", 10)
+	res, _ := io.ReadAll(r.Replace(iotest.OneByteReader(strings.NewReader(content))))
+	if expect := "xxx" + content[6:]; string(res) != expect {
+		t.Errorf("should %s but %s", expect, res)
+	}
+}
@@ -0,0 +1,63 @@
+package bytespool
+
+import (
+	"errors"
+	"github.com/eleztian/pipe/bytespool/fixed"
+	"sort"
+)
+
+// AutoPool is a slab allocator routing each request to the fixed.Allocator of the
+// smallest size class that fits it.
+type AutoPool struct {
+	sizes []int // ascending
+	pools []*fixed.Allocator
+}
+
+// NewAutoPool returns a pool with a size class of sizes[i] bytes keeping up to
+// bufNums[i] buffers for every i.
+func NewAutoPool(sizes []int, bufNums []int) *AutoPool {
+	if len(sizes) != len(bufNums) {
+		panic("bytespool.NewAutoPool: sizes and bufNums lengths differ")
+	}
+	idx := make([]int, len(sizes))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return sizes[idx[i]] < sizes[idx[j]] })
+
+	p := &AutoPool{}
+	for _, i := range idx {
+		if sizes[i] <= 0 {
+			panic("bytespool.NewAutoPool: sizes must be positive")
+		}
+		if n := len(p.sizes); n > 0 && p.sizes[n-1] == sizes[i] {
+			panic("bytespool.NewAutoPool: duplicate size")
+		}
+		p.sizes = append(p.sizes, sizes[i])
+		p.pools = append(p.pools, fixed.NewBytePool(bufNums[i], sizes[i]))
+	}
+	return p
+}
+
+// Get returns a buffer of size bytes from the smallest size class that fits it,
+// nil if size exceeds all of them.
+func (p *AutoPool) Get(size int) []byte {
+	if size <= 0 {
+		return nil
+	}
+	i := sort.SearchInts(p.sizes, size)
+	if i == len(p.sizes) {
+		return nil
+	}
+	return p.pools[i].Get(p.sizes[i])[:size]
+}
+
+// Put returns a buffer obtained by Get to its size class. The class is found from
+// the capacity of b, as Get may have shortened it.
+func (p *AutoPool) Put(b []byte) error {
+	i := sort.SearchInts(p.sizes, cap(b))
+	if i == len(p.sizes) || p.sizes[i] != cap(b) {
+		return errors.New("bytespool.AutoPool: buffer does not belong to a size class")
+	}
+	return p.pools[i].Put(b[:cap(b)])
+}
@@ -0,0 +1,25 @@
+package bytespool
+
+import "testing"
+
+func TestAutoPool(t *testing.T) {
+	var _ BytesPool = (*AutoPool)(nil)
+
+	p := NewAutoPool([]int{1024, 64, 256}, []int{1, 1, 1})
+	b := p.Get(100)
+	if len(b) != 100 || cap(b) != 256 {
+		t.Fatalf("should get 100 bytes from the 256 class but len %d cap %d", len(b), cap(b))
+	}
+	if err := p.Put(b); err != nil {
+		t.Fatal(err)
+	}
+	if b2 := p.Get(200); &b2[0] != &b[0] {
+		t.Errorf("buffer is not reused")
+	}
+	if p.Get(2048) != nil {
+		t.Errorf("should not serve sizes above the largest class")
+	}
+	if err := p.Put(make([]byte, 100)); err == nil {
+		t.Errorf("should reject a foreign buffer")
+	}
+}
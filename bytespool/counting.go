@@ -0,0 +1,47 @@
+package bytespool
+
+import "sync/atomic"
+
+// CountingPool wraps a BytesPool and counts the bytes handed out and given back,
+// which helps finding buffers that are never returned to the pool.
+type CountingPool struct {
+	allocated int64
+	freed     int64
+	inner     BytesPool
+}
+
+func NewCountingPool(inner BytesPool) *CountingPool {
+	return &CountingPool{inner: inner}
+}
+
+// Get returns a buffer of inner, counting its length as allocated.
+func (p *CountingPool) Get(size int) []byte {
+	b := p.inner.Get(size)
+	atomic.AddInt64(&p.allocated, int64(len(b)))
+	return b
+}
+
+// Put gives the buffer back to inner, counting its length as freed if inner
+// accepts it.
+func (p *CountingPool) Put(b []byte) error {
+	if err := p.inner.Put(b); err != nil {
+		return err
+	}
+	atomic.AddInt64(&p.freed, int64(len(b)))
+	return nil
+}
+
+// AllocatedBytes returns the total length of the buffers returned by Get.
+func (p *CountingPool) AllocatedBytes() int64 {
+	return atomic.LoadInt64(&p.allocated)
+}
+
+// FreedBytes returns the total length of the buffers given back by Put.
+func (p *CountingPool) FreedBytes() int64 {
+	return atomic.LoadInt64(&p.freed)
+}
+
+// OutstandingBytes returns the length of the buffers not given back yet.
+func (p *CountingPool) OutstandingBytes() int64 {
+	return p.AllocatedBytes() - p.FreedBytes()
+}
@@ -0,0 +1,23 @@
+package bytespool
+
+import (
+	"github.com/eleztian/pipe/bytespool/fixed"
+	"testing"
+)
+
+func TestCountingPool(t *testing.T) {
+	p := NewCountingPool(fixed.NewBytePool(2, 8))
+	a, b := p.Get(8), p.Get(8)
+	if p.Get(4) != nil {
+		t.Fatal("should not get a buffer of the wrong size")
+	}
+	if err := p.Put(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Put(b[:4]); err == nil {
+		t.Fatal("should reject a buffer of the wrong size")
+	}
+	if p.AllocatedBytes() != 16 || p.FreedBytes() != 8 || p.OutstandingBytes() != 8 {
+		t.Errorf("unexpected counters %d %d %d", p.AllocatedBytes(), p.FreedBytes(), p.OutstandingBytes())
+	}
+}
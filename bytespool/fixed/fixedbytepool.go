@@ -1,16 +1,86 @@
 package fixed
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the allocator's counters.
+type Stats struct {
+	Hits   int64 // Get served from the free list
+	Misses int64 // Get had to create a new buffer
+	Drops  int64 // Put discarded the buffer because the free list was full
+}
 
 type Allocator struct {
+	hits     int64
+	misses   int64
+	drops    int64
 	freeList chan []byte
 	bufSize  int // size of each buffer
+	factory  func(int) []byte
+	poison   bool
+	pattern  byte
+	retired  int // buffer size of the allocator this one was resized from
 }
 
-func NewBytePool(bufNum int, bufSize int) *Allocator {
-	return &Allocator{
+// Option configures an Allocator.
+type Option func(*Allocator)
+
+// WithPoisoning fills every buffer given back by Put with pattern and checks on
+// Get that it still is, panicking otherwise. This catches writes to a buffer
+// after it was returned to the pool, at the cost of touching every byte twice,
+// so it is meant for tests.
+func WithPoisoning(pattern byte) Option {
+	return func(fp *Allocator) {
+		fp.poison = true
+		fp.pattern = pattern
+	}
+}
+
+func NewBytePool(bufNum int, bufSize int, opts ...Option) *Allocator {
+	return NewBytePoolWithFactory(bufNum, bufSize, nil, opts...)
+}
+
+// NewBytePoolWithFactory is like NewBytePool but creates new buffers by calling
+// factory(bufSize) instead of make([]byte, bufSize). This allows the caller to
+// hand out pre-initialized buffers. A nil factory falls back to make.
+func NewBytePoolWithFactory(bufNum int, bufSize int, factory func(int) []byte, opts ...Option) *Allocator {
+	if factory == nil {
+		factory = func(size int) []byte {
+			return make([]byte, size)
+		}
+	}
+	fp := &Allocator{
 		freeList: make(chan []byte, bufNum),
 		bufSize:  bufSize,
+		factory:  factory,
+	}
+	for _, opt := range opts {
+		opt(fp)
+	}
+	return fp
+}
+
+// check panics if b was written to since it was poisoned by Put.
+func (fp *Allocator) check(b []byte) []byte {
+	if fp.poison {
+		for _, c := range b {
+			if c != fp.pattern {
+				panic("fixed.Allocator: buffer modified after Put")
+			}
+		}
+	}
+	return b
+}
+
+func (fp *Allocator) fill(b []byte) {
+	if fp.poison {
+		for i := range b {
+			b[i] = fp.pattern
+		}
 	}
 }
 
@@ -21,24 +91,163 @@ func (fp *Allocator) Get(size int) (b []byte) {
 	}
 	select {
 	case b = <-fp.freeList:
+		atomic.AddInt64(&fp.hits, 1)
+		fp.check(b)
 	default:
-		b = make([]byte, fp.bufSize)
+		atomic.AddInt64(&fp.misses, 1)
+		b = fp.factory(fp.bufSize)
 	}
 	return
 }
 
+// GetContext returns a buffer from the fixed size pool buffer. Unlike Get it only
+// creates a new buffer while fewer than Cap buffers have been created so far;
+// after that it blocks until a buffer is Put back or ctx is done, in which case
+// it returns nil and ctx.Err(). This bounds the memory used by the pool.
+func (fp *Allocator) GetContext(ctx context.Context, size int) ([]byte, error) {
+	if fp.bufSize != size {
+		return nil, errors.New("invalid buffer size that's requested from fixed size pool buffer")
+	}
+	select {
+	case b := <-fp.freeList:
+		atomic.AddInt64(&fp.hits, 1)
+		return fp.check(b), nil
+	default:
+	}
+	for {
+		misses := atomic.LoadInt64(&fp.misses)
+		if misses >= int64(cap(fp.freeList)) {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&fp.misses, misses, misses+1) {
+			return fp.factory(fp.bufSize), nil
+		}
+	}
+	select {
+	case b := <-fp.freeList:
+		atomic.AddInt64(&fp.hits, 1)
+		return fp.check(b), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // Put add the buffer into the free buffer pool for reuse. return error if the buffer
 // size is not the same with the fixed size pool buffer's. This is intended to expose
 // error usage of fixed size pool buffer.
 func (fp *Allocator) Put(b []byte) error {
 	if len(b) != fp.bufSize {
+		if len(b) == fp.retired && fp.retired != 0 {
+			atomic.AddInt64(&fp.drops, 1)
+			return nil
+		}
 		return errors.New("invalid buffer size that's put into fixed size pool buffer")
 	}
+	fp.fill(b)
 
 	select {
 	case fp.freeList <- b:
 	default:
+		atomic.AddInt64(&fp.drops, 1)
 	}
 
 	return nil
 }
+
+// GetBatch returns n buffers, taking as many as available from the free list and
+// creating the rest, like n calls to Get but with a single pass over the free list.
+func (fp *Allocator) GetBatch(n int) [][]byte {
+	bufs := make([][]byte, 0, n)
+	hits := 0
+loop:
+	for len(bufs) < n {
+		select {
+		case b := <-fp.freeList:
+			bufs = append(bufs, fp.check(b))
+			hits++
+		default:
+			break loop
+		}
+	}
+	atomic.AddInt64(&fp.hits, int64(hits))
+	atomic.AddInt64(&fp.misses, int64(n-hits))
+	for len(bufs) < n {
+		bufs = append(bufs, fp.factory(fp.bufSize))
+	}
+	return bufs
+}
+
+// PutBatch adds the buffers into the free buffer pool like Put. It returns an
+// error without adding any of them if one has the wrong size.
+func (fp *Allocator) PutBatch(bufs [][]byte) error {
+	for _, b := range bufs {
+		if len(b) != fp.bufSize {
+			return errors.New("invalid buffer size that's put into fixed size pool buffer")
+		}
+	}
+	for i, b := range bufs {
+		fp.fill(b)
+		select {
+		case fp.freeList <- b:
+		default:
+			// the free list is full, the remaining buffers are dropped.
+			atomic.AddInt64(&fp.drops, int64(len(bufs)-i))
+			return nil
+		}
+	}
+	return nil
+}
+
+// Resize returns a new allocator of buffers of newBufSize bytes, with the same
+// capacity, factory and options, for a workload needing other sizes. The free
+// list of fp is drained; buffers of the old size still in use can be Put to
+// either allocator, the new one drops them without error.
+func (fp *Allocator) Resize(newBufSize int) *Allocator {
+drain:
+	for {
+		select {
+		case <-fp.freeList:
+		default:
+			break drain
+		}
+	}
+	return &Allocator{
+		freeList: make(chan []byte, cap(fp.freeList)),
+		bufSize:  newBufSize,
+		factory:  fp.factory,
+		poison:   fp.poison,
+		pattern:  fp.pattern,
+		retired:  fp.bufSize,
+	}
+}
+
+// Stats returns the current hit, miss and drop counters.
+func (fp *Allocator) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadInt64(&fp.hits),
+		Misses: atomic.LoadInt64(&fp.misses),
+		Drops:  atomic.LoadInt64(&fp.drops),
+	}
+}
+
+// Len returns the number of buffers currently held in the free list.
+func (fp *Allocator) Len() int {
+	return len(fp.freeList)
+}
+
+// Cap returns the maximum number of buffers the free list can hold.
+func (fp *Allocator) Cap() int {
+	return cap(fp.freeList)
+}
+
+// RegisterExpvar publishes the allocator counters as name.hits, name.misses,
+// name.drops, name.len and name.cap, so they show up in /debug/vars. The values
+// are read when the variables are rendered. Like expvar.Publish it panics if a
+// name is already registered.
+func (fp *Allocator) RegisterExpvar(name string) {
+	expvar.Publish(name+".hits", expvar.Func(func() interface{} { return atomic.LoadInt64(&fp.hits) }))
+	expvar.Publish(name+".misses", expvar.Func(func() interface{} { return atomic.LoadInt64(&fp.misses) }))
+	expvar.Publish(name+".drops", expvar.Func(func() interface{} { return atomic.LoadInt64(&fp.drops) }))
+	expvar.Publish(name+".len", expvar.Func(func() interface{} { return fp.Len() }))
+	expvar.Publish(name+".cap", expvar.Func(func() interface{} { return fp.Cap() }))
+}
@@ -0,0 +1,144 @@
+package fixed
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+)
+
+func TestNewBytePoolWithFactory(t *testing.T) {
+	calls := 0
+	pool := NewBytePoolWithFactory(1, 8, func(size int) []byte {
+		calls++
+		b := make([]byte, size)
+		for i := range b {
+			b[i] = 0xff
+		}
+		return b
+	})
+
+	b := pool.Get(8)
+	if calls != 1 || len(b) != 8 || b[0] != 0xff {
+		t.Fatalf("factory not used on miss: calls=%d buf=%v", calls, b)
+	}
+	if err := pool.Put(b); err != nil {
+		t.Fatal(err)
+	}
+	_ = pool.Get(8)
+	if calls != 1 {
+		t.Errorf("factory should not be called on hit, calls=%d", calls)
+	}
+}
+
+func TestAllocator_GetContext(t *testing.T) {
+	pool := NewBytePool(1, 8)
+
+	b, err := pool.GetContext(context.Background(), 8)
+	if err != nil || len(b) != 8 {
+		t.Fatalf("unexpected result: %v %v", b, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.GetContext(ctx, 8); err != context.DeadlineExceeded {
+		t.Fatalf("should block until deadline, got %v", err)
+	}
+
+	go func() {
+		_ = pool.Put(b)
+	}()
+	b2, err := pool.GetContext(context.Background(), 8)
+	if err != nil || len(b2) != 8 {
+		t.Fatalf("unexpected result: %v %v", b2, err)
+	}
+
+	if _, err := pool.GetContext(context.Background(), 4); err == nil {
+		t.Error("size mismatch should return error")
+	}
+}
+
+func TestAllocator_RegisterExpvar(t *testing.T) {
+	pool := NewBytePool(2, 8)
+	pool.RegisterExpvar("fixed_test")
+	_ = pool.Put(pool.Get(8))
+
+	if v := expvar.Get("fixed_test.misses").String(); v != "1" {
+		t.Errorf("misses should be 1 but %s", v)
+	}
+	if v := expvar.Get("fixed_test.len").String(); v != "1" {
+		t.Errorf("len should be 1 but %s", v)
+	}
+	if v := expvar.Get("fixed_test.cap").String(); v != "2" {
+		t.Errorf("cap should be 2 but %s", v)
+	}
+}
+
+func TestAllocator_GetBatch(t *testing.T) {
+	pool := NewBytePool(4, 8)
+	if err := pool.PutBatch([][]byte{make([]byte, 8), make([]byte, 8)}); err != nil {
+		t.Fatal(err)
+	}
+	bufs := pool.GetBatch(3)
+	if len(bufs) != 3 || len(bufs[2]) != 8 {
+		t.Fatalf("unexpected batch %v", bufs)
+	}
+	if st := pool.Stats(); st.Hits != 2 || st.Misses != 1 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+
+	if err := pool.PutBatch([][]byte{make([]byte, 8), make([]byte, 4)}); err == nil || pool.Len() != 0 {
+		t.Errorf("should reject the batch, len %d", pool.Len())
+	}
+	if err := pool.PutBatch(append(bufs, make([]byte, 8), make([]byte, 8))); err != nil {
+		t.Fatal(err)
+	}
+	if st := pool.Stats(); pool.Len() != 4 || st.Drops != 1 {
+		t.Errorf("unexpected len %d stats %+v", pool.Len(), st)
+	}
+}
+
+func TestWithPoisoning(t *testing.T) {
+	pool := NewBytePool(2, 4, WithPoisoning(0xAA))
+
+	b := pool.Get(4)
+	copy(b, "data")
+	if err := pool.Put(b); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range b {
+		if c != 0xAA {
+			t.Fatalf("buffer not poisoned on Put: %x", b)
+		}
+	}
+	b = pool.Get(4) // untouched, must not panic
+	_ = pool.Put(b)
+
+	b[0] = 'x' // use after Put
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Get should panic on a buffer modified after Put")
+		}
+	}()
+	pool.Get(4)
+}
+
+func TestResize(t *testing.T) {
+	pool := NewBytePool(2, 4)
+	old := pool.Get(4)
+	_ = pool.Put(pool.Get(4))
+
+	resized := pool.Resize(8)
+	if pool.Len() != 0 {
+		t.Errorf("the old free list should be drained")
+	}
+	if b := resized.Get(8); len(b) != 8 {
+		t.Errorf("should get buffers of the new size but %d", len(b))
+	}
+	if err := resized.Put(old); err != nil || resized.Len() != 0 || resized.Stats().Drops != 1 {
+		t.Errorf("old size buffers should be dropped: %v %+v", err, resized.Stats())
+	}
+	if err := resized.Put(make([]byte, 5)); err == nil {
+		t.Errorf("should reject other sizes")
+	}
+}
@@ -0,0 +1,89 @@
+package bytespool
+
+import (
+	"github.com/eleztian/pipe/bytespool/fixed"
+	"sync"
+	"sync/atomic"
+)
+
+// GrowablePool is a fixed size pool buffer that doubles the number of buffers it
+// keeps once the pool turns out to be too small for the workload.
+//
+// The pool is considered too small when, within a window of bufNum Get calls, the
+// ratio Misses / (Hits + Misses) exceeds threshold. Buffers already cached are
+// carried over to the grown pool. Get and Put only take a lock once per window,
+// to check the ratio.
+type GrowablePool struct {
+	state     atomic.Value // *growableState
+	bufSize   int
+	threshold float64
+	mu        sync.Mutex  // serializes the checks
+	base      fixed.Stats // counters at the start of the current window, guarded by mu
+}
+
+// growableState is the current pool and its window counter, replaced as a whole
+// when the pool grows.
+type growableState struct {
+	gets   int64 // updated atomically
+	pool   *fixed.Allocator
+	bufNum int
+}
+
+func NewGrowablePool(bufNum int, bufSize int, threshold float64) *GrowablePool {
+	if bufNum <= 0 {
+		panic("bytespool.NewGrowablePool: bufNum must be positive")
+	}
+	p := &GrowablePool{
+		bufSize:   bufSize,
+		threshold: threshold,
+	}
+	p.state.Store(&growableState{pool: fixed.NewBytePool(bufNum, bufSize), bufNum: bufNum})
+	return p
+}
+
+// Get returns a buffer of bufSize bytes, nil if size differs from bufSize.
+func (p *GrowablePool) Get(size int) []byte {
+	s := p.state.Load().(*growableState)
+	b := s.pool.Get(size)
+	if b != nil && atomic.AddInt64(&s.gets, 1)%int64(s.bufNum) == 0 {
+		p.check(s)
+	}
+	return b
+}
+
+// Put returns the buffer to the current pool.
+func (p *GrowablePool) Put(b []byte) error {
+	return p.state.Load().(*growableState).pool.Put(b)
+}
+
+// BufNum returns the current number of buffers the pool can keep.
+func (p *GrowablePool) BufNum() int {
+	return p.state.Load().(*growableState).bufNum
+}
+
+// check ends the window of s, growing the pool if it had too many misses.
+func (p *GrowablePool) check(s *growableState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.Load() != s {
+		// the pool grew meanwhile.
+		return
+	}
+
+	st := s.pool.Stats()
+	hits := st.Hits - p.base.Hits
+	misses := st.Misses - p.base.Misses
+	if hits+misses == 0 || float64(misses)/float64(hits+misses) <= p.threshold {
+		p.base = st
+		return
+	}
+
+	grown := &growableState{pool: fixed.NewBytePool(s.bufNum*2, p.bufSize), bufNum: s.bufNum * 2}
+	// Get and Put keep using s.pool until the new state is stored: move the
+	// buffers cached now, a live Len could keep the loop going.
+	for n := s.pool.Len(); n > 0; n-- {
+		_ = grown.pool.Put(s.pool.Get(p.bufSize))
+	}
+	p.base = grown.pool.Stats()
+	p.state.Store(grown)
+}
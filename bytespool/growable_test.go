@@ -0,0 +1,77 @@
+package bytespool
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGrowablePool(t *testing.T) {
+	pool := NewGrowablePool(2, 8, 0.4)
+
+	for round := 0; round < 4; round++ {
+		bufs := make([][]byte, 4)
+		for i := range bufs {
+			bufs[i] = pool.Get(8)
+		}
+		for _, b := range bufs {
+			if err := pool.Put(b); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if pool.BufNum() < 4 {
+		t.Errorf("pool should have grown, bufNum=%d", pool.BufNum())
+	}
+
+	if pool.Get(16) != nil {
+		t.Error("size mismatch should return nil")
+	}
+}
+
+func TestGrowablePool_GrowsAfterDrops(t *testing.T) {
+	pool := NewGrowablePool(2, 8, 0.4)
+	// dropped buffers do not prevent growing later.
+	for i := 0; i < 4; i++ {
+		_ = pool.Put(make([]byte, 8))
+	}
+	for round := 0; round < 2; round++ {
+		bufs := make([][]byte, 8)
+		for i := range bufs {
+			bufs[i] = pool.Get(8)
+		}
+		for _, b := range bufs {
+			_ = pool.Put(b)
+		}
+	}
+	if pool.BufNum() < 4 {
+		t.Errorf("pool should have grown, bufNum=%d", pool.BufNum())
+	}
+}
+
+func TestGrowablePool_Concurrent(t *testing.T) {
+	pool := NewGrowablePool(1, 8, 0.5)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				b := pool.Get(8)
+				_ = pool.Put(b)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := pool.BufNum(); n < 1 || n&(n-1) != 0 {
+		t.Errorf("bufNum should be a power of two, got %d", n)
+	}
+}
+
+func TestGrowablePool_InvalidBufNum(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("should panic on a non positive bufNum")
+		}
+	}()
+	NewGrowablePool(0, 8, 0.5)
+}
@@ -0,0 +1,130 @@
+package ladder
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// LadderPool is an Allocator that watches the sizes requested by Get and gives
+// the frequent ones a dedicated bucket of buffers of exactly that size, instead of
+// rounding them up to the next power of two.
+type LadderPool struct {
+	gets uint64 // updated atomically, kept first for 64-bit alignment
+
+	*Allocator
+
+	window     []int64      // last requested sizes, used as a ring and updated atomically
+	buckets    atomic.Value // map[int]*sync.Pool, replaced as a whole on promotion
+	maxBuckets int
+	mu         sync.Mutex // serializes the promotions
+}
+
+// NewAdaptiveLadderPool returns a LadderPool tracking the sizes of the last
+// initial Get calls. At the end of each such window, the sizes making up a
+// quarter of it are promoted to a dedicated bucket, up to max buckets.
+func NewAdaptiveLadderPool(initial, max int) *LadderPool {
+	if initial <= 0 {
+		panic("ladder.NewAdaptiveLadderPool: initial window must be positive")
+	}
+	p := &LadderPool{
+		Allocator:  NewAllocator(),
+		window:     make([]int64, initial),
+		maxBuckets: max,
+	}
+	p.buckets.Store(map[int]*sync.Pool{})
+	return p
+}
+
+// Get a []byte from the dedicated bucket of size if any, from the ladder otherwise.
+func (p *LadderPool) Get(size int) []byte {
+	if size <= 0 {
+		return nil
+	}
+	p.observe(size)
+	if bucket := p.bucket(size); bucket != nil {
+		return bucket.Get().([]byte)
+	}
+	return p.Allocator.Get(size)
+}
+
+// Put returns a []byte to its dedicated bucket if any, to the ladder otherwise.
+func (p *LadderPool) Put(buf []byte) error {
+	if bucket := p.bucket(cap(buf)); bucket != nil {
+		bucket.Put(buf[:cap(buf)])
+		return nil
+	}
+	return p.Allocator.Put(buf)
+}
+
+// Buckets returns the sizes that have a dedicated bucket.
+func (p *LadderPool) Buckets() []int {
+	buckets := p.buckets.Load().(map[int]*sync.Pool)
+	res := make([]int, 0, len(buckets))
+	for size := range buckets {
+		res = append(res, size)
+	}
+	return res
+}
+
+func (p *LadderPool) bucket(size int) *sync.Pool {
+	return p.buckets.Load().(map[int]*sync.Pool)[size]
+}
+
+// observe records a request of size, promoting sizes at the end of each window.
+func (p *LadderPool) observe(size int) {
+	n := atomic.AddUint64(&p.gets, 1)
+	atomic.StoreInt64(&p.window[(n-1)%uint64(len(p.window))], int64(size))
+	if n%uint64(len(p.window)) == 0 {
+		p.promote()
+	}
+}
+
+// promote gives the sizes making up a quarter of the window a dedicated bucket,
+// the most frequent first, then the ones requested first.
+func (p *LadderPool) promote() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	buckets := p.buckets.Load().(map[int]*sync.Pool)
+	if len(buckets) >= p.maxBuckets {
+		return
+	}
+	counts := make(map[int]int)
+	var sizes []int // in order of first request
+	for i := range p.window {
+		size := int(atomic.LoadInt64(&p.window[i]))
+		// powers of two are already exact in the ladder.
+		if size <= 0 || (size&(size-1) == 0 && size <= 65536) || buckets[size] != nil {
+			continue
+		}
+		if counts[size] == 0 {
+			sizes = append(sizes, size)
+		}
+		counts[size]++
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return counts[sizes[i]] > counts[sizes[j]]
+	})
+
+	grown := buckets
+	for _, size := range sizes {
+		if len(grown) >= p.maxBuckets || counts[size]*4 < len(p.window) {
+			break
+		}
+		if len(grown) == len(buckets) {
+			// copy on write, Get and Put read buckets without lock.
+			grown = make(map[int]*sync.Pool, len(buckets)+1)
+			for s, b := range buckets {
+				grown[s] = b
+			}
+		}
+		size := size
+		grown[size] = &sync.Pool{New: func() interface{} {
+			return make([]byte, size)
+		}}
+	}
+	if len(grown) != len(buckets) {
+		p.buckets.Store(grown)
+	}
+}
@@ -0,0 +1,44 @@
+package ladder
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAdaptiveLadderPool(t *testing.T) {
+	pool := NewAdaptiveLadderPool(16, 1)
+
+	for i := 0; i < 8; i++ {
+		if err := pool.Put(pool.Get(3000)); err != nil {
+			t.Fatal(err)
+		}
+		_ = pool.Put(pool.Get(1000))
+	}
+	if b := pool.Get(3000); len(b) != 3000 || cap(b) != 3000 {
+		t.Errorf("3000 should have a dedicated bucket, got len %d cap %d", len(b), cap(b))
+	}
+	if b := pool.Get(1000); cap(b) != 1024 {
+		t.Errorf("bucket count is limited, 1000 should come from the ladder, got cap %d", cap(b))
+	}
+	if b := pool.Get(4096); cap(b) != 4096 {
+		t.Errorf("unexpected cap %d", cap(b))
+	}
+}
+
+func TestAdaptiveLadderPool_Concurrent(t *testing.T) {
+	pool := NewAdaptiveLadderPool(64, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = pool.Put(pool.Get(3000))
+			}
+		}()
+	}
+	wg.Wait()
+	if b := pool.Buckets(); len(b) != 1 || b[0] != 3000 {
+		t.Errorf("3000 should have a dedicated bucket, got %v", b)
+	}
+}
@@ -0,0 +1,84 @@
+package bytespool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// cacheLineSize is large enough for the cache lines, or pairs of lines fetched
+// together, of common CPUs.
+const cacheLineSize = 128
+
+// localShard holds the counters of a shard, padded to a cache line so that shards
+// updated by different goroutines do not contend.
+type localShard struct {
+	hits  int64
+	nils  int64
+	drops int64
+	_     [cacheLineSize - 3*8]byte
+}
+
+// LocalStats are the counters of a LocalCountingPool.
+type LocalStats struct {
+	Hits  int64 // Get returned a buffer
+	Nils  int64 // Get returned nil
+	Drops int64 // Put returned an error
+}
+
+// LocalCountingPool wraps a BytesPool and counts its Get and Put calls, spreading
+// the counters over shards to avoid contention on a single cache line. Like
+// sync.Pool, each processor keeps using the same shard.
+type LocalCountingPool struct {
+	inner  BytesPool
+	shards []localShard
+	local  sync.Pool // *localShard of the calling processor
+	next   uint32    // next shard for local.New, only written when local is empty
+}
+
+func NewLocalCountingPool(inner BytesPool, shards int) *LocalCountingPool {
+	if shards <= 0 {
+		panic("bytespool.NewLocalCountingPool: shards must be positive")
+	}
+	p := &LocalCountingPool{inner: inner, shards: make([]localShard, shards)}
+	p.local.New = func() interface{} {
+		i := atomic.AddUint32(&p.next, 1)
+		return &p.shards[i%uint32(len(p.shards))]
+	}
+	return p
+}
+
+// count adds one to the counter of the calling processor's shard picked by field.
+func (p *LocalCountingPool) count(field func(*localShard) *int64) {
+	s := p.local.Get().(*localShard)
+	atomic.AddInt64(field(s), 1)
+	p.local.Put(s)
+}
+
+func (p *LocalCountingPool) Get(size int) []byte {
+	b := p.inner.Get(size)
+	if b != nil {
+		p.count(func(s *localShard) *int64 { return &s.hits })
+	} else {
+		p.count(func(s *localShard) *int64 { return &s.nils })
+	}
+	return b
+}
+
+func (p *LocalCountingPool) Put(b []byte) error {
+	err := p.inner.Put(b)
+	if err != nil {
+		p.count(func(s *localShard) *int64 { return &s.drops })
+	}
+	return err
+}
+
+// Merge returns the sum of the counters of all shards.
+func (p *LocalCountingPool) Merge() LocalStats {
+	var st LocalStats
+	for i := range p.shards {
+		st.Hits += atomic.LoadInt64(&p.shards[i].hits)
+		st.Nils += atomic.LoadInt64(&p.shards[i].nils)
+		st.Drops += atomic.LoadInt64(&p.shards[i].drops)
+	}
+	return st
+}
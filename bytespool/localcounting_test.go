@@ -0,0 +1,26 @@
+package bytespool
+
+import (
+	"github.com/eleztian/pipe/bytespool/fixed"
+	"sync"
+	"testing"
+)
+
+func TestLocalCountingPool(t *testing.T) {
+	p := NewLocalCountingPool(fixed.NewBytePool(4, 8), 8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = p.Put(p.Get(8))
+				_ = p.Get(4)
+			}
+		}()
+	}
+	wg.Wait()
+	if st := p.Merge(); st.Hits != 800 || st.Nils != 800 || st.Drops != 0 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+}
@@ -0,0 +1,92 @@
+package libio
+
+import "io"
+
+// states of the C comment stripper.
+const (
+	cCode      = iota
+	cSlash     // '/' in code, maybe starting a comment
+	cLine      // in a // comment
+	cBlock     // in a /* comment
+	cBlockStar // '*' in a /* comment, maybe ending it
+	cString    // in a "string"
+	cStringEsc // after '\' in a string
+	cChar      // in a 'c' character constant
+	cCharEsc   // after '\' in a character constant
+)
+
+// NewCCommentStripper returns a reader of the C source src without its comments.
+// Like the C preprocessor, a /* */ comment is replaced with a space, a // comment
+// is removed up to the end of the line, which is kept, and comments do not nest: a
+// /* inside a comment is part of it. Comment markers in string literals and
+// character constants are left alone. A comment unterminated at EOF is removed up
+// to the end.
+func NewCCommentStripper(src io.Reader) io.Reader {
+	state := cCode
+	return newTransformReader(src, func(dst, p []byte) []byte {
+		for _, b := range p {
+			if state == cSlash {
+				switch b {
+				case '/':
+					state = cLine
+					continue
+				case '*':
+					state = cBlock
+					continue
+				}
+				// not a comment, b is handled as code.
+				dst = append(dst, '/')
+				state = cCode
+			}
+
+			switch state {
+			case cCode:
+				switch b {
+				case '/':
+					state = cSlash
+					continue
+				case '"':
+					state = cString
+				case '\'':
+					state = cChar
+				}
+				dst = append(dst, b)
+			case cLine:
+				if b == '\n' {
+					dst = append(dst, b)
+					state = cCode
+				}
+			case cBlock:
+				if b == '*' {
+					state = cBlockStar
+				}
+			case cBlockStar:
+				switch b {
+				case '/':
+					dst = append(dst, ' ')
+					state = cCode
+				case '*':
+				default:
+					state = cBlock
+				}
+			case cString, cChar:
+				switch {
+				case b == '\\':
+					state++ // to the escape state
+				case b == '"' && state == cString, b == '\'' && state == cChar:
+					state = cCode
+				}
+				dst = append(dst, b)
+			case cStringEsc, cCharEsc:
+				state--
+				dst = append(dst, b)
+			}
+		}
+		return dst
+	}, func(dst []byte) []byte {
+		if state == cSlash {
+			dst = append(dst, '/')
+		}
+		return dst
+	})
+}
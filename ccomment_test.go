@@ -0,0 +1,27 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCCommentStripper(t *testing.T) {
+	for _, c := range []struct{ src, want string }{
+		{"int a; // comment\nint b;", "int a; \nint b;"},
+		{"a/* x */b", "a b"},
+		{"a /* /* x */ b */", "a   b */"},
+		{"a /** x **/ b", "a   b"},
+		{`s = "// no /* comment */"; // c`, `s = "// no /* comment */"; `},
+		{`s = "\"//"; c = '"'; // x`, `s = "\"//"; c = '"'; `},
+		{"x = a / b; y = a/", "x = a / b; y = a/"},
+		{"a /* unterminated", "a "},
+		{"a // at EOF", "a "},
+	} {
+		res, err := io.ReadAll(NewCCommentStripper(iotest.OneByteReader(strings.NewReader(c.src))))
+		if err != nil || string(res) != c.want {
+			t.Errorf("%q: should %q but %q %v", c.src, c.want, res, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Command replacergen statically checks libio.NewReplacer calls whose arguments are
+// string literals: the argument count must be even and no search token may be
+// empty. It is meant to be run by go generate:
+//
+//	//go:generate go run github.com/eleztian/pipe/cmd/replacergen
+//
+// Without arguments it checks $GOFILE, otherwise the files given on the command
+// line. It exits with status 1 if any problem is found.
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+)
+
+const libioPath = "github.com/eleztian/pipe"
+
+func main() {
+	files := os.Args[1:]
+	if len(files) == 0 {
+		if gofile := os.Getenv("GOFILE"); gofile != "" {
+			files = []string{gofile}
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: replacergen file.go...")
+		os.Exit(2)
+	}
+
+	failed := false
+	fset := token.NewFileSet()
+	for _, name := range files {
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		for _, msg := range check(fset, f) {
+			fmt.Fprintln(os.Stderr, msg)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// check returns the problems found in the NewReplacer calls of f.
+func check(fset *token.FileSet, f *ast.File) []string {
+	pkgName := ""
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == libioPath {
+			pkgName = "libio"
+			if imp.Name != nil {
+				pkgName = imp.Name.Name
+			}
+		}
+	}
+	if pkgName == "" || pkgName == "_" {
+		return nil
+	}
+
+	var res []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || call.Ellipsis.IsValid() {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "NewReplacer" {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); !ok || id.Name != pkgName {
+			return true
+		}
+
+		args := make([]string, 0, len(call.Args))
+		for _, arg := range call.Args {
+			lit, ok := arg.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true // not all literals, nothing to validate statically
+			}
+			s, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return true
+			}
+			args = append(args, s)
+		}
+
+		if len(args)%2 == 1 {
+			res = append(res, fmt.Sprintf("%s: NewReplacer: odd argument count", fset.Position(call.Pos())))
+		}
+		for i := 0; i < len(args); i += 2 {
+			if args[i] == "" {
+				res = append(res, fmt.Sprintf("%s: NewReplacer: empty search token", fset.Position(call.Args[i].Pos())))
+			}
+		}
+		return true
+	})
+	return res
+}
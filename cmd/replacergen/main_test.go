@@ -0,0 +1,33 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	src := `package p
+
+import lio "github.com/eleztian/pipe"
+
+var (
+	a = lio.NewReplacer("a", "b")
+	b = lio.NewReplacer("a", "b", "c")
+	c = lio.NewReplacer("", "b")
+	d = lio.NewReplacer(x, "")
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := check(fset, f)
+	if len(res) != 2 {
+		t.Fatalf("should find 2 problems but %v", res)
+	}
+	if res[0] != "p.go:7:6: NewReplacer: odd argument count" || res[1] != "p.go:8:22: NewReplacer: empty search token" {
+		t.Errorf("unexpected problems %v", res)
+	}
+}
@@ -0,0 +1,47 @@
+package libio
+
+import "io"
+
+// NewDecompressReplacingReader returns a reader of the content of src, decoded by
+// decompressor, with the tokens of replacer replaced, e.g. with a decompressor
+// wrapping flate.NewReader.
+func NewDecompressReplacingReader(src io.Reader, decompressor func(io.Reader) io.Reader, replacer Replacer) io.Reader {
+	return replacer.Replace(decompressor(src))
+}
+
+type compressingReader struct {
+	pr   *io.PipeReader
+	done chan struct{}
+}
+
+// NewReplaceCompressingReader returns a reader of src with the tokens of replacer
+// replaced and then encoded by the writer returned by compressor, e.g. a
+// gzip.Writer. As compressors are writers, the content is replaced and compressed
+// as it is read by a goroutine writing to a pipe, which Close stops and waits for.
+func NewReplaceCompressingReader(src io.Reader, replacer Replacer, compressor func(io.Writer) io.WriteCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	zw := compressor(pw)
+
+	c := &compressingReader{pr: pr, done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		_, err := Copy(zw, replacer.Replace(src))
+		if err == nil {
+			err = zw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return c
+}
+
+func (c *compressingReader) Read(p []byte) (int, error) {
+	return c.pr.Read(p)
+}
+
+// Close stops the pipeline and waits for its goroutine to return, which happens
+// once its pending read of the source returns.
+func (c *compressingReader) Close() error {
+	_ = c.pr.Close()
+	<-c.done
+	return nil
+}
@@ -0,0 +1,33 @@
+package libio
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompressReplacingReaders(t *testing.T) {
+	replacer := NewReplacer("foo", "bar")
+	src := strings.Repeat("foo baz ", 1000)
+	want := strings.Repeat("bar baz ", 1000)
+
+	compressed := NewReplaceCompressingReader(strings.NewReader(src), replacer, func(w io.Writer) io.WriteCloser {
+		zw, _ := flate.NewWriter(w, flate.BestSpeed)
+		return zw
+	})
+	defer compressed.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, compressed); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() >= len(src) {
+		t.Errorf("output is not compressed: %d bytes", buf.Len())
+	}
+
+	res, err := io.ReadAll(NewDecompressReplacingReader(&buf, func(r io.Reader) io.Reader { return flate.NewReader(r) }, NewReplacer("bar", "qux")))
+	if want := strings.ReplaceAll(want, "bar", "qux"); err != nil || string(res) != want {
+		t.Errorf("round trip failed: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package libio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReplacerConfig describes a Replacer in a form that can be loaded from a
+// configuration file.
+type ReplacerConfig struct {
+	Rules []ReplacerRule
+}
+
+// ReplacerRule is a rule of a ReplacerConfig. Search is compared exactly, like
+// NewReplacer does, or under case-folding if CaseInsensitive is set, and is
+// replaced at most MaxCount times per stream, without limit if MaxCount is 0.
+type ReplacerRule struct {
+	Search          string
+	Replace         string
+	CaseInsensitive bool
+	MaxCount        int
+}
+
+// NewReplacerFromConfig returns the Replacer described by cfg. Like the Replacer
+// built by ReplacerBuilder, rules are matched leftmost first and on a tie the rule
+// given first wins.
+func NewReplacerFromConfig(cfg ReplacerConfig) (Replacer, error) {
+	if len(cfg.Rules) == 0 {
+		return nil, errors.New("libio.NewReplacerFromConfig: no replace rules")
+	}
+	r := &replacer{minRatio: -1}
+	limited := false
+	for i, rule := range cfg.Rules {
+		if rule.Search == "" {
+			return nil, fmt.Errorf("libio.NewReplacerFromConfig: rule %d: search token cannot be empty", i)
+		}
+		if rule.MaxCount < 0 {
+			return nil, fmt.Errorf("libio.NewReplacerFromConfig: rule %d: negative max count", i)
+		}
+		if rule.CaseInsensitive {
+			r.add(&foldReplace{search: []byte(rule.Search), replace: []byte(rule.Replace)})
+		} else {
+			r.add(&byteReplace{search: []byte(rule.Search), replace: []byte(rule.Replace)})
+		}
+		limited = limited || rule.MaxCount > 0
+	}
+	if !limited {
+		return r, nil
+	}
+
+	maxCounts := make([]int, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		maxCounts[i] = rule.MaxCount
+	}
+	return &ruleLimitReplacer{rules: r, maxCounts: maxCounts}, nil
+}
+
+// ruleLimitReplacer stops replacing each rule after its own count of replacements
+// per stream.
+type ruleLimitReplacer struct {
+	rules     *replacer
+	maxCounts []int // 0 for no limit
+}
+
+func (l *ruleLimitReplacer) newBytesReplacer() BytesReplacer {
+	return &ruleLimitIndex{
+		replacer:  l.rules,
+		remaining: append([]int(nil), l.maxCounts...),
+		winner:    -1,
+	}
+}
+
+func (l *ruleLimitReplacer) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, l.newBytesReplacer())
+}
+
+// ruleLimitIndex holds the per stream counts of a ruleLimitReplacer.
+type ruleLimitIndex struct {
+	*replacer
+	remaining []int // replacements left per rule, negative once exhausted
+	winner    int   // rule of the match returned by the last Index call
+}
+
+func (l *ruleLimitIndex) Index(buf []byte) (resIndex int, resSearch []byte, resReplace []byte) {
+	resIndex, l.winner = -1, -1
+	for i, er := range l.replaces {
+		if l.remaining[i] < 0 {
+			continue
+		}
+		index, search, replace := er.Index(buf)
+		if index >= 0 && (resIndex == -1 || index < resIndex) {
+			resIndex, resSearch, resReplace = index, search, replace
+			l.winner = i
+		}
+	}
+	return
+}
+
+func (l *ruleLimitIndex) Matched(offset int64, search, replace []byte) {
+	if l.winner < 0 || l.remaining[l.winner] == 0 {
+		return
+	}
+	if l.remaining[l.winner]--; l.remaining[l.winner] == 0 {
+		l.remaining[l.winner] = -1
+	}
+}
+
+func (l *ruleLimitIndex) clone() (BytesReplacer, bool) {
+	c := *l
+	c.remaining = append([]int(nil), l.remaining...)
+	return &c, true
+}
@@ -0,0 +1,31 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNewReplacerFromConfig(t *testing.T) {
+	r, err := NewReplacerFromConfig(ReplacerConfig{Rules: []ReplacerRule{
+		{Search: "foo", Replace: "bar", MaxCount: 2},
+		{Search: "baz", Replace: "qux", CaseInsensitive: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		res, _ := io.ReadAll(r.Replace(iotest.OneByteReader(strings.NewReader("foo FOO foo foo BAZ baz"))))
+		if want := "bar FOO bar foo qux qux"; string(res) != want {
+			t.Errorf("should %s but %s", want, res)
+		}
+	}
+
+	if _, err = NewReplacerFromConfig(ReplacerConfig{Rules: []ReplacerRule{{Search: ""}}}); err == nil {
+		t.Errorf("should fail on an empty search token")
+	}
+	if _, err = NewReplacerFromConfig(ReplacerConfig{}); err == nil {
+		t.Errorf("should fail without rules")
+	}
+}
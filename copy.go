@@ -1,12 +1,174 @@
 package libio
 
 import (
+	"context"
+	"errors"
 	"github.com/eleztian/pipe/bytespool/ladder"
 	"io"
+	"os"
+	"time"
 )
 
+// Copy copies from src to dst like io.Copy, using a pooled buffer when neither
+// dst implements io.ReaderFrom nor src implements io.WriterTo.
 func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	if rf, ok := dst.(io.ReaderFrom); ok {
+		return rf.ReadFrom(src)
+	}
+	if wt, ok := src.(io.WriterTo); ok {
+		return wt.WriteTo(dst)
+	}
 	buf := ladder.Get(32 * 1024)
 	defer ladder.Put(buf)
 	return io.CopyBuffer(dst, src, buf)
 }
+
+// CopyWithDeadline is like Copy but gives up once deadline has passed, returning
+// context.DeadlineExceeded. If src supports SetReadDeadline, as net.Conn does, the
+// deadline is set on src so that a blocked Read is interrupted too; otherwise the
+// deadline is checked before each Read.
+func CopyWithDeadline(dst io.Writer, src io.Reader, deadline time.Time) (int64, error) {
+	if conn, ok := src.(interface{ SetReadDeadline(t time.Time) error }); ok {
+		if err := conn.SetReadDeadline(deadline); err == nil {
+			defer conn.SetReadDeadline(time.Time{})
+			n, err := Copy(dst, src)
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				err = context.DeadlineExceeded
+			}
+			return n, err
+		}
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	return Copy(dst, &ctxReader{ctx: ctx, r: src})
+}
+
+// ctxReader fails reads once ctx is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// CopyWithMinChunk copies from src to dst, accumulating reads until at least
+// minChunk bytes are buffered (or src is exhausted) before writing them at once.
+// This reduces the number of writes for sources returning small chunks, at the
+// cost of latency. minChunk must be positive.
+func CopyWithMinChunk(dst io.Writer, src io.Reader, minChunk int) (int64, error) {
+	if minChunk <= 0 {
+		panic("libio.CopyWithMinChunk: min chunk must be positive")
+	}
+	size := max(minChunk, 32*1024)
+	buf := ladder.Get(size)
+	if buf == nil {
+		buf = make([]byte, size)
+	} else {
+		defer ladder.Put(buf)
+	}
+
+	var written int64
+	for {
+		filled := 0
+		var rerr error
+		for filled < minChunk && rerr == nil {
+			var n int
+			n, rerr = src.Read(buf[filled:])
+			filled += n
+		}
+		if filled > 0 {
+			n, err := dst.Write(buf[:filled])
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+			if n != filled {
+				return written, io.ErrShortWrite
+			}
+		}
+		if rerr == io.EOF {
+			return written, nil
+		}
+		if rerr != nil {
+			return written, rerr
+		}
+	}
+}
+
+// CopyAtLeast copies from src to dst until EOF like Copy, but returns
+// io.ErrUnexpectedEOF if src is exhausted before atLeast bytes have been copied.
+func CopyAtLeast(dst io.Writer, src io.Reader, atLeast int64) (int64, error) {
+	n, err := Copy(dst, src)
+	if err == nil && n < atLeast {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// CopyRange copies the bytes of src from start up to end, exclusive, to dst, as
+// needed to serve an HTTP Range request. It returns io.ErrUnexpectedEOF if src ends
+// before end.
+func CopyRange(dst io.Writer, src io.ReadSeeker, start, end int64) (int64, error) {
+	if start < 0 || end < start {
+		return 0, errors.New("libio.CopyRange: invalid range")
+	}
+	if _, err := src.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return CopyAtLeast(dst, &io.LimitedReader{R: src, N: end - start}, end-start)
+}
+
+// CopyWithBuffer is io.CopyBuffer: it copies from src to dst through buf, never
+// taking a buffer from the ladder pool, for callers managing their own buffers. A
+// nil buf is allocated, and like io.CopyBuffer, buf is not used if dst implements
+// io.ReaderFrom or src implements io.WriterTo.
+func CopyWithBuffer(dst io.Writer, src io.Reader, buf []byte) (int64, error) {
+	return io.CopyBuffer(dst, src, buf)
+}
+
+// FanOutCopyDetailed copies src to every writer of dsts, returning the bytes
+// written to and the error of each one. A writer failing is dropped while the
+// others keep being written to, and the copy stops once src is exhausted or all
+// the writers failed. An error reading src is reported for every writer still
+// being written to.
+func FanOutCopyDetailed(src io.Reader, dsts []io.Writer) ([]int64, []error) {
+	written := make([]int64, len(dsts))
+	errs := make([]error, len(dsts))
+	live := len(dsts)
+	buf := ladder.Get(32 * 1024)
+	defer ladder.Put(buf)
+	for live > 0 {
+		n, rerr := src.Read(buf)
+		for i, dst := range dsts {
+			if n == 0 || errs[i] != nil {
+				continue
+			}
+			nw, err := dst.Write(buf[:n])
+			written[i] += int64(nw)
+			if err == nil && nw != n {
+				err = io.ErrShortWrite
+			}
+			if err != nil {
+				errs[i] = err
+				live--
+			}
+		}
+		if rerr != nil {
+			if rerr != io.EOF {
+				for i := range errs {
+					if errs[i] == nil {
+						errs[i] = rerr
+					}
+				}
+			}
+			break
+		}
+	}
+	return written, errs
+}
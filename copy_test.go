@@ -0,0 +1,145 @@
+package libio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+type slowReader struct {
+	delay time.Duration
+}
+
+func (s slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	p[0] = 'x'
+	return 1, nil
+}
+
+func TestCopyWithDeadline(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := CopyWithDeadline(&buf, strings.NewReader("hello"), time.Now().Add(time.Second))
+	if err != nil || n != 5 || buf.String() != "hello" {
+		t.Fatalf("unexpected result %d %v %s", n, err, buf.String())
+	}
+
+	_, err = CopyWithDeadline(&buf, slowReader{delay: 5 * time.Millisecond}, time.Now().Add(30*time.Millisecond))
+	if err != context.DeadlineExceeded {
+		t.Errorf("should deadline exceeded but %v", err)
+	}
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	_, err = CopyWithDeadline(&buf, c1, time.Now().Add(30*time.Millisecond))
+	if err != context.DeadlineExceeded {
+		t.Errorf("should deadline exceeded but %v", err)
+	}
+}
+
+type readerFromWriter struct {
+	bytes.Buffer
+	readFrom bool
+}
+
+func (w *readerFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.readFrom = true
+	return w.Buffer.ReadFrom(r)
+}
+
+func TestCopy_FastPath(t *testing.T) {
+	w := &readerFromWriter{}
+	n, err := Copy(w, strings.NewReader("hello"))
+	if err != nil || n != 5 || !w.readFrom || w.String() != "hello" {
+		t.Errorf("should use ReadFrom: %d %v %v %s", n, err, w.readFrom, w.String())
+	}
+}
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestCopyWithMinChunk(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	w := &countingWriter{}
+	n, err := CopyWithMinChunk(w, iotest.OneByteReader(strings.NewReader(content)), 100)
+	if err != nil || n != int64(len(content)) || w.String() != content {
+		t.Fatalf("unexpected result %d %v", n, err)
+	}
+	if w.writes != 10 {
+		t.Errorf("should write 10 times but %d", w.writes)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("should panic on a non positive min chunk")
+		}
+	}()
+	_, _ = CopyWithMinChunk(w, strings.NewReader(content), 0)
+}
+
+func TestCopyAtLeast(t *testing.T) {
+	w := &countingWriter{}
+	n, err := CopyAtLeast(w, strings.NewReader("hello"), 5)
+	if err != nil || n != 5 || w.String() != "hello" {
+		t.Fatalf("unexpected result %d %v", n, err)
+	}
+
+	n, err = CopyAtLeast(&countingWriter{}, strings.NewReader("hello"), 6)
+	if err != io.ErrUnexpectedEOF || n != 5 {
+		t.Errorf("should %v but %d %v", io.ErrUnexpectedEOF, n, err)
+	}
+}
+
+func TestCopyRange(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := CopyRange(&buf, strings.NewReader("0123456789"), 2, 5)
+	if err != nil || n != 3 || buf.String() != "234" {
+		t.Fatalf("unexpected result %d %v %s", n, err, buf.String())
+	}
+
+	if _, err = CopyRange(&bytes.Buffer{}, strings.NewReader("0123456789"), 8, 12); err != io.ErrUnexpectedEOF {
+		t.Errorf("should %v but %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestCopyWithBuffer(t *testing.T) {
+	w := &countingWriter{}
+	buf := make([]byte, 3)
+	// hide ReadFrom so that buf is used.
+	n, err := CopyWithBuffer(struct{ io.Writer }{w}, iotest.HalfReader(strings.NewReader("hello world")), buf)
+	if err != nil || n != 11 || w.String() != "hello world" {
+		t.Fatalf("unexpected result %d %v", n, err)
+	}
+	if w.writes < 4 {
+		t.Errorf("should write through the 3 bytes buffer but wrote %d times", w.writes)
+	}
+}
+
+func TestFanOutCopyDetailed(t *testing.T) {
+	var a, b bytes.Buffer
+	src := strings.Repeat("x", 100*1024)
+	written, errs := FanOutCopyDetailed(strings.NewReader(src), []io.Writer{&a, errWriter{}, &b})
+	if written[0] != int64(len(src)) || written[2] != int64(len(src)) || a.String() != src || b.String() != src {
+		t.Errorf("healthy writers should get the whole source, written %v", written)
+	}
+	if errs[0] != nil || errs[1] == nil || errs[2] != nil || written[1] != 0 {
+		t.Errorf("only the failing writer should report an error: %v %v", written, errs)
+	}
+
+	_, errs = FanOutCopyDetailed(iotest.TimeoutReader(strings.NewReader(src)), []io.Writer{&a})
+	if errs[0] != iotest.ErrTimeout {
+		t.Errorf("read errors should be reported, got %v", errs[0])
+	}
+}
@@ -0,0 +1,79 @@
+package libio
+
+import (
+	"io"
+	"sync"
+)
+
+// CountingReplacer records how many times each search token was replaced by the
+// Replacer returned along with it by NewCountingReplacer.
+type CountingReplacer struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// NewCountingReplacer returns a Replacer behaving like inner and a
+// CountingReplacer counting its replacements over all the streams it replaces.
+// Matches are counted under the text they matched, which is the search token
+// except for replacers matching several spellings of it, like NewFoldReplacer.
+// Replacers that are not built on StreamReplacingReader are delegated to as is and
+// their matches are not counted.
+func NewCountingReplacer(inner Replacer) (*CountingReplacer, Replacer) {
+	c := &CountingReplacer{counts: make(map[string]int64)}
+	return c, &countingReplacer{inner: inner, counter: c}
+}
+
+// Counts returns a copy of the number of replacements per search token.
+func (c *CountingReplacer) Counts() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make(map[string]int64, len(c.counts))
+	for k, v := range c.counts {
+		res[k] = v
+	}
+	return res
+}
+
+type countingReplacer struct {
+	inner   Replacer
+	counter *CountingReplacer
+}
+
+func (r *countingReplacer) Replace(src io.Reader) io.Reader {
+	br, ok := bytesReplacerOf(r.inner)
+	if !ok {
+		return r.inner.Replace(src)
+	}
+	return (&StreamReplacingReader{}).ResetEx(src, &countingIndex{BytesReplacer: br, counter: r.counter})
+}
+
+// countingIndex counts the matches of a stream in its CountingReplacer.
+type countingIndex struct {
+	BytesReplacer
+	counter *CountingReplacer
+}
+
+func (c *countingIndex) SetOffset(offset int64) {
+	if os, ok := c.BytesReplacer.(OffsetSetter); ok {
+		os.SetOffset(offset)
+	}
+}
+
+func (c *countingIndex) Matched(offset int64, search, replace []byte) {
+	c.counter.mu.Lock()
+	c.counter.counts[string(search)]++
+	c.counter.mu.Unlock()
+	if mo, ok := c.BytesReplacer.(MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+}
+
+func (c *countingIndex) clone() (BytesReplacer, bool) {
+	inner, ok := cloneBytesReplacer(c.BytesReplacer)
+	return &countingIndex{BytesReplacer: inner, counter: c.counter}, ok
+}
+
+func (c *countingIndex) stopAtMatch() bool {
+	s, ok := c.BytesReplacer.(stopper)
+	return ok && s.stopAtMatch()
+}
@@ -0,0 +1,28 @@
+package libio
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCountingReplacer(t *testing.T) {
+	counter, r := NewCountingReplacer(NewReplacer("foo", "F", "bar", "B", "baz", "Z"))
+
+	for _, src := range []string{"foo bar foo", "barfoo"} {
+		out, err := ioutil.ReadAll(r.Replace(iotest.OneByteReader(strings.NewReader(src))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.NewReplacer("foo", "F", "bar", "B", "baz", "Z").Replace(src); string(out) != want {
+			t.Errorf("got %q, want %q", out, want)
+		}
+	}
+
+	want := map[string]int64{"foo": 3, "bar": 2}
+	if got := counter.Counts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got counts %v, want %v", got, want)
+	}
+}
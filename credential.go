@@ -0,0 +1,87 @@
+package libio
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	basicAuthPrefix = []byte("Authorization: Basic ")
+	redacted        = []byte("REDACTED")
+)
+
+const (
+	// longest base64 credentials considered, longer tokens are left alone.
+	maxCredentialLen = 1024
+	// "dTpw" is the shortest token, for "u:p".
+	minCredentialLen = 4
+)
+
+type credentialScrubber struct{}
+
+// NewCredentialScrubber returns a Replacer that replaces the credentials following
+// "Authorization: Basic " (the header name and scheme are matched regardless of
+// case) with REDACTED. A token is only replaced if it decodes to a printable
+// "username:password", so that other uses of the header are left alone.
+func NewCredentialScrubber() Replacer {
+	return credentialScrubber{}
+}
+
+func (credentialScrubber) GetSizingHints() (int, int, float64) {
+	return len(basicAuthPrefix) + maxCredentialLen, len(redacted), float64(minCredentialLen) / float64(len(redacted))
+}
+
+func (credentialScrubber) Index(buf []byte) (int, []byte, []byte) {
+	for off := 0; off < len(buf); {
+		i := foldIndex(buf[off:], basicAuthPrefix)
+		if i < 0 {
+			return -1, nil, nil
+		}
+		start := off + i + len(basicAuthPrefix)
+		end := start
+		for end < len(buf) && end-start <= maxCredentialLen && isBase64Char(buf[end]) {
+			end++
+		}
+		// a token reaching the end of buf is reported even though it may go on: the
+		// reader does not replace it before it is known to be complete.
+		if token := buf[start:end]; isCredential(token) {
+			return start, token, redacted
+		}
+		off = start
+	}
+	return -1, nil, nil
+}
+
+func (c credentialScrubber) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, c)
+}
+
+func isBase64Char(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '+' || c == '/' || c == '='
+}
+
+// isCredential reports whether token is the base64 encoding of a printable
+// "username:password".
+func isCredential(token []byte) bool {
+	if len(token) < minCredentialLen || len(token) > maxCredentialLen {
+		return false
+	}
+	dec := make([]byte, base64.StdEncoding.DecodedLen(len(token)))
+	n, err := base64.StdEncoding.Decode(dec, token)
+	if err != nil {
+		return false
+	}
+	dec = dec[:n]
+	if bytes.IndexByte(dec, ':') < 0 || !utf8.Valid(dec) {
+		return false
+	}
+	for _, r := range string(dec) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
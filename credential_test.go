@@ -0,0 +1,21 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCredentialScrubber(t *testing.T) {
+	src := "GET / HTTP/1.1\r\nAuthorization: Basic dXNlcjpzZWNyZXQ=\r\n" +
+		"authorization: basic Zm9vYmFy\r\n" + // no colon, not credentials
+		"Authorization: Basic dTpw"
+	want := "GET / HTTP/1.1\r\nAuthorization: Basic REDACTED\r\n" +
+		"authorization: basic Zm9vYmFy\r\n" +
+		"Authorization: Basic REDACTED"
+	res, _ := io.ReadAll(NewCredentialScrubber().Replace(iotest.OneByteReader(strings.NewReader(src))))
+	if string(res) != want {
+		t.Errorf("should %q but %q", want, res)
+	}
+}
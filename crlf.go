@@ -0,0 +1,30 @@
+package libio
+
+// WithCRLFNormalization makes the reader turn every "\r\n" of the source into "\n"
+// before searching it, so that a token like "foo\nbar" also matches "foo\r\nbar",
+// and the output only has "\n" line endings. Source offsets reported to
+// OffsetSetter and MatchObserver do not account for the removed bytes.
+func WithCRLFNormalization(enabled bool) ReaderOption {
+	return func(r *StreamReplacingReader) {
+		r.crlf = enabled
+	}
+}
+
+// normalizeCRLF removes the '\r' of every "\r\n" in p, just read from the source,
+// and returns the length left. Unless eof, a '\r' ending p is held back in r.cr as
+// it may be followed by a '\n' in the next read.
+func (r *StreamReplacingReader) normalizeCRLF(p []byte, eof bool) int {
+	w := 0
+	for i, b := range p {
+		if b == '\r' && i+1 < len(p) && p[i+1] == '\n' {
+			continue
+		}
+		p[w] = b
+		w++
+	}
+	if !eof && w > 0 && p[w-1] == '\r' {
+		w--
+		r.cr = true
+	}
+	return w
+}
@@ -0,0 +1,48 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestStreamReplacingReader_WithCRLFNormalization(t *testing.T) {
+	src := "foo\r\nbar\r\rfoo\nbar\r\n\r"
+	want := "X\r\rX\n\r"
+	for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.OneByteReader} {
+		r := NewStreamReplacingReader(wrap(strings.NewReader(src)), &byteReplace{search: []byte("foo\nbar"), replace: []byte("X")}, WithCRLFNormalization(true))
+		res, err := io.ReadAll(r)
+		if err != nil || string(res) != want {
+			t.Errorf("should %q but %q %v", want, res, err)
+		}
+	}
+
+	res, _ := io.ReadAll(NewStreamReplacingReader(strings.NewReader(src), &byteReplace{search: []byte("foo\nbar"), replace: []byte("X")}))
+	if want := "foo\r\nbar\r\rX\r\n\r"; string(res) != want {
+		t.Errorf("should not normalize by default: %q", res)
+	}
+}
+
+func TestStreamReplacingReader_WithCRLFNormalizationFullBuffer(t *testing.T) {
+	// the first read fills the buffer, held back for the long token, and ends with
+	// '\r'.
+	search := strings.Repeat("a", defaultBufSize)
+	src := strings.Repeat("y", defaultBufSize-1) + "\r\nzzz"
+	done := make(chan struct{})
+	var res []byte
+	var err error
+	go func() {
+		defer close(done)
+		res, err = io.ReadAll(NewStreamReplacingReader(strings.NewReader(src), &byteReplace{search: []byte(search), replace: []byte("b")}, WithCRLFNormalization(true)))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("read does not finish")
+	}
+	if want := strings.Replace(src, "\r\n", "\n", 1); err != nil || string(res) != want {
+		t.Errorf("should normalize the source, got %d bytes %v", len(res), err)
+	}
+}
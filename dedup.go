@@ -0,0 +1,23 @@
+package libio
+
+import "io"
+
+// NewDeduplicateReader returns a reader of src in which every run of consecutive
+// c bytes is collapsed into a single c, e.g. to squeeze repeated spaces.
+func NewDeduplicateReader(src io.Reader, c byte) io.Reader {
+	prev := false // the last byte written is c
+	return newTransformReader(src, func(dst, p []byte) []byte {
+		for _, b := range p {
+			if b == c {
+				if prev {
+					continue
+				}
+				prev = true
+			} else {
+				prev = false
+			}
+			dst = append(dst, b)
+		}
+		return dst
+	}, nil)
+}
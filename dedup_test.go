@@ -0,0 +1,15 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDeduplicateReader(t *testing.T) {
+	res, err := io.ReadAll(NewDeduplicateReader(iotest.OneByteReader(strings.NewReader("  a   b c  ")), ' '))
+	if err != nil || string(res) != " a b c " {
+		t.Errorf("should %q but %q %v", " a b c ", res, err)
+	}
+}
@@ -0,0 +1,82 @@
+package libio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+type diffReplacer struct {
+	inner    Replacer
+	patchDst io.Writer
+}
+
+// NewDiffReplacer returns a Replacer that replaces like inner and writes a hunk to
+// patchDst for every replacement made, in a unified-diff like format:
+//
+//	@@ -<source offset>,<old length> +<output offset>,<new length> @@
+//	-<old value, quoted>
+//	+<new value, quoted>
+//
+// inner must be built on StreamReplacingReader, as the replacers of this package
+// are. Errors writing to patchDst are returned by Read.
+func NewDiffReplacer(inner Replacer, patchDst io.Writer) Replacer {
+	if _, ok := bytesReplacerOf(inner); !ok {
+		panic("libio.NewDiffReplacer: inner replacer is not based on StreamReplacingReader")
+	}
+	return &diffReplacer{inner: inner, patchDst: patchDst}
+}
+
+func (d *diffReplacer) Replace(src io.Reader) io.Reader {
+	br, ok := bytesReplacerOf(d.inner)
+	if !ok {
+		panic("libio.NewDiffReplacer: inner replacer is not based on StreamReplacingReader")
+	}
+	di := &diffIndex{BytesReplacer: br, w: d.patchDst}
+	return &diffReader{r: (&StreamReplacingReader{}).ResetEx(src, di), di: di}
+}
+
+// diffIndex writes a hunk for each match of the BytesReplacer it wraps.
+type diffIndex struct {
+	BytesReplacer
+	w     io.Writer
+	delta int64 // output offset - source offset
+	err   error
+}
+
+func (d *diffIndex) SetOffset(offset int64) {
+	if os, ok := d.BytesReplacer.(OffsetSetter); ok {
+		os.SetOffset(offset)
+	}
+}
+
+func (d *diffIndex) stopAtMatch() bool {
+	s, ok := d.BytesReplacer.(stopper)
+	return ok && s.stopAtMatch()
+}
+
+func (d *diffIndex) Matched(offset int64, search, replace []byte) {
+	if mo, ok := d.BytesReplacer.(MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+	outOffset := offset + d.delta
+	d.delta += int64(len(replace) - len(search))
+	if d.err != nil || bytes.Equal(search, replace) {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, "@@ -%d,%d +%d,%d @@\n-%q\n+%q\n",
+		offset, len(search), outOffset, len(replace), search, replace)
+}
+
+type diffReader struct {
+	r  io.Reader
+	di *diffIndex
+}
+
+func (d *diffReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if d.di.err != nil {
+		return n, d.di.err
+	}
+	return n, err
+}
@@ -0,0 +1,24 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDiffReplacer(t *testing.T) {
+	var patch bytes.Buffer
+	r := NewDiffReplacer(NewReplacer("foo", "ab", "x", "x"), &patch)
+	res, err := io.ReadAll(r.Replace(strings.NewReader("foo x foo")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res) != "ab x ab" {
+		t.Errorf("should ab x ab but %s", res)
+	}
+	expect := "@@ -0,3 +0,2 @@\n-\"foo\"\n+\"ab\"\n@@ -6,3 +5,2 @@\n-\"foo\"\n+\"ab\"\n"
+	if patch.String() != expect {
+		t.Errorf("should %s but %s", expect, patch.String())
+	}
+}
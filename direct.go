@@ -0,0 +1,68 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool/ladder"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// CopyDirect copies from src to dst like Copy, for a dst opened with O_DIRECT,
+// which requires writes of a multiple of blockSize bytes from a buffer aligned on
+// blockSize. The last block is padded with zeros and the file is then truncated to
+// the bytes actually copied. dst is written from its current offset.
+func CopyDirect(dst *os.File, src io.Reader, blockSize int) (int64, error) {
+	if blockSize <= 0 || blockSize&(blockSize-1) != 0 {
+		panic("libio.CopyDirect: block size must be a power of two")
+	}
+	start, err := dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	size := max(blockSize, 32*1024/blockSize*blockSize)
+	raw := ladder.Get(size + blockSize)
+	if raw == nil {
+		raw = make([]byte, size+blockSize)
+	} else {
+		defer ladder.Put(raw)
+	}
+	buf := alignBuf(raw, blockSize)[:size]
+
+	var written int64
+	for {
+		n, rerr := io.ReadFull(src, buf)
+		if n > 0 {
+			padded := (n + blockSize - 1) / blockSize * blockSize
+			for i := n; i < padded; i++ {
+				buf[i] = 0
+			}
+			if _, err := dst.Write(buf[:padded]); err != nil {
+				return written, err
+			}
+			written += int64(n)
+			if padded != n {
+				// only the last read can be short: drop the padding.
+				if err := dst.Truncate(start + written); err != nil {
+					return written, err
+				}
+			}
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			return written, nil
+		}
+		if rerr != nil {
+			return written, rerr
+		}
+	}
+}
+
+// alignBuf returns the part of b starting at the first address aligned on align,
+// which must be a power of two; b must be at least align bytes longer than needed.
+func alignBuf(b []byte, align int) []byte {
+	off := int(uintptr(unsafe.Pointer(&b[0])) & uintptr(align-1))
+	if off == 0 {
+		return b
+	}
+	return b[align-off:]
+}
@@ -0,0 +1,32 @@
+package libio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unsafe"
+)
+
+func TestCopyDirect(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	content := strings.Repeat("0123456789", 1000)
+	n, err := CopyDirect(f, iotest.HalfReader(strings.NewReader(content)), 512)
+	if err != nil || n != int64(len(content)) {
+		t.Fatalf("unexpected result %d %v", n, err)
+	}
+	res, _ := os.ReadFile(f.Name())
+	if string(res) != content {
+		t.Errorf("unexpected content of %d bytes", len(res))
+	}
+
+	if got := alignBuf(make([]byte, 2*4096), 4096); len(got) < 4096 || uintptr(unsafe.Pointer(&got[0]))%4096 != 0 {
+		t.Errorf("buffer is not aligned")
+	}
+}
@@ -0,0 +1,95 @@
+package libio
+
+import (
+	"errors"
+	"github.com/eleztian/pipe/bytespool/ladder"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+	"io"
+)
+
+var errInconsistentByteCount = errors.New("libio: transformer consumed more bytes than given")
+
+// NewEncodingReader returns a reader transcoding src from the from character
+// encoding to the to one, e.g. from charmap.ISO8859_1 to unicode.UTF8. The text is
+// decoded to UTF-8 and encoded again, so a rune that to can not represent is
+// reported as an error by its encoder. The intermediate buffers are taken from the
+// ladder pool and given back at the end of the stream.
+func NewEncodingReader(src io.Reader, from, to encoding.Encoding) io.Reader {
+	return &encodingReader{
+		r: src,
+		t: transform.Chain(from.NewDecoder(), to.NewEncoder()),
+	}
+}
+
+// encodingReader is transform.Reader with pooled buffers: src[src0:src1] is read
+// but not transformed yet and dst[dst0:dst1] is transformed but not delivered.
+type encodingReader struct {
+	r          io.Reader
+	t          transform.Transformer
+	err        error
+	done       bool
+	src, dst   []byte
+	src0, src1 int
+	dst0, dst1 int
+}
+
+func (e *encodingReader) Read(p []byte) (int, error) {
+	if e.src == nil && !e.done {
+		e.src = ladder.Get(transformBufSize)
+		e.dst = ladder.Get(transformBufSize)
+	}
+	for {
+		if e.dst0 != e.dst1 {
+			n := copy(p, e.dst[e.dst0:e.dst1])
+			e.dst0 += n
+			return n, nil
+		}
+		if e.done {
+			e.release()
+			return 0, e.err
+		}
+
+		if e.src0 != e.src1 || e.err != nil {
+			var n int
+			var err error
+			e.dst0 = 0
+			e.dst1, n, err = e.t.Transform(e.dst, e.src[e.src0:e.src1], e.err == io.EOF)
+			e.src0 += n
+
+			switch {
+			case err == nil:
+				if e.src0 != e.src1 {
+					e.err = errInconsistentByteCount
+				}
+				e.done = e.err != nil
+				continue
+			case err == transform.ErrShortDst && (e.dst1 != 0 || n != 0):
+				continue
+			case err == transform.ErrShortSrc && e.src1-e.src0 != len(e.src) && e.err == nil:
+				// read more source below
+			default:
+				e.done = true
+				if e.err == nil || e.err == io.EOF {
+					e.err = err
+				}
+				continue
+			}
+		}
+
+		if e.src0 != 0 {
+			e.src0, e.src1 = 0, copy(e.src, e.src[e.src0:e.src1])
+		}
+		var n int
+		n, e.err = e.r.Read(e.src[e.src1:])
+		e.src1 += n
+	}
+}
+
+func (e *encodingReader) release() {
+	if e.src != nil {
+		_ = ladder.Put(e.src)
+		_ = ladder.Put(e.dst)
+		e.src, e.dst = nil, nil
+	}
+}
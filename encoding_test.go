@@ -0,0 +1,48 @@
+package libio
+
+import (
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncodingReader(t *testing.T) {
+	latin1 := "caf\xe9 na\xefve \xa9"
+	utf8 := "café naïve ©"
+
+	for _, c := range []struct {
+		name     string
+		src      string
+		from, to encoding.Encoding
+		want     string
+	}{
+		{"latin1 to utf8", latin1, charmap.ISO8859_1, unicode.UTF8, utf8},
+		{"utf8 to latin1", utf8, unicode.UTF8, charmap.ISO8859_1, latin1},
+	} {
+		for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.OneByteReader} {
+			out, err := ioutil.ReadAll(NewEncodingReader(wrap(strings.NewReader(c.src)), c.from, c.to))
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			if string(out) != c.want {
+				t.Errorf("%s: got %q, want %q", c.name, out, c.want)
+			}
+		}
+	}
+
+	long := strings.Repeat(latin1, 1000)
+	out, err := ioutil.ReadAll(NewEncodingReader(strings.NewReader(long), charmap.ISO8859_1, unicode.UTF8))
+	if err != nil || string(out) != strings.Repeat(utf8, 1000) {
+		t.Errorf("long input is not transcoded, err %v", err)
+	}
+
+	_, err = ioutil.ReadAll(NewEncodingReader(strings.NewReader("日本"), unicode.UTF8, charmap.ISO8859_1))
+	if err == nil {
+		t.Errorf("should fail on runes the target encoding can not represent")
+	}
+}
@@ -0,0 +1,115 @@
+package libio
+
+import "io"
+
+type fieldReplace struct {
+	delimiter  byte
+	fieldIndex int
+	inner      BytesReplacer
+
+	// per stream state: the field at the source offset base, and the delimiters
+	// and line ends of the last buffer searched, which starts at base.
+	offset int64
+	base   int64
+	field  int
+	marks  []fieldMark
+}
+
+// fieldMark is a delimiter, or a line end if newline, at a source offset.
+type fieldMark struct {
+	offset  int64
+	newline bool
+}
+
+// NewFieldReplacer returns a BytesReplacer that applies inner only within the
+// fieldIndex-th (0-based) field of each line, fields being separated by delimiter,
+// e.g. to replace only the third segment of header values. If delimiter is '\n'
+// the fields are the lines of the whole stream. The fields are tracked across the
+// buffers of the stream, so the BytesReplacer must be used for a single stream; its
+// Replace method gives each stream its own state.
+func NewFieldReplacer(delimiter byte, fieldIndex int, inner BytesReplacer) BytesReplacer {
+	if fieldIndex < 0 {
+		panic("libio.NewFieldReplacer: negative field index")
+	}
+	return &fieldReplace{delimiter: delimiter, fieldIndex: fieldIndex, inner: inner}
+}
+
+func (f *fieldReplace) newBytesReplacer() BytesReplacer {
+	return &fieldReplace{delimiter: f.delimiter, fieldIndex: f.fieldIndex, inner: f.inner}
+}
+
+func (f *fieldReplace) clone() (BytesReplacer, bool) {
+	c := *f
+	c.marks = append([]fieldMark(nil), f.marks...)
+	var ok bool
+	c.inner, ok = cloneBytesReplacer(f.inner)
+	return &c, ok
+}
+
+func (f *fieldReplace) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, f.newBytesReplacer())
+}
+
+func (f *fieldReplace) GetSizingHints() (int, int, float64) {
+	return f.inner.GetSizingHints()
+}
+
+func (f *fieldReplace) SetOffset(offset int64) {
+	f.offset = offset
+}
+
+func (f *fieldReplace) Matched(offset int64, search, replace []byte) {
+	if mo, ok := f.inner.(MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+}
+
+func (f *fieldReplace) Index(buf []byte) (int, []byte, []byte) {
+	// buf starts within the last buffer searched, at or after its start: move the
+	// state to its start.
+	for _, m := range f.marks {
+		if m.offset >= f.offset {
+			break
+		}
+		f.advance(m.newline)
+	}
+	f.base = f.offset
+	f.marks = f.marks[:0]
+	for i, c := range buf {
+		if c == f.delimiter || (c == '\n' && f.delimiter != '\n') {
+			f.marks = append(f.marks, fieldMark{offset: f.base + int64(i), newline: c != f.delimiter})
+		}
+	}
+
+	field, start := f.field, 0
+	for i := 0; i <= len(f.marks); i++ {
+		end := len(buf)
+		if i < len(f.marks) {
+			end = int(f.marks[i].offset - f.base)
+		}
+		if field == f.fieldIndex {
+			if os, ok := f.inner.(OffsetSetter); ok {
+				os.SetOffset(f.base + int64(start))
+			}
+			if index, search, replace := f.inner.Index(buf[start:end]); index >= 0 {
+				return start + index, search, replace
+			}
+		}
+		if i < len(f.marks) && f.marks[i].newline {
+			field = 0
+		} else {
+			field++
+		}
+		start = end + 1
+	}
+	return -1, nil, nil
+}
+
+// advance moves the field state past a delimiter or a line end.
+func (f *fieldReplace) advance(newline bool) {
+	if newline {
+		f.field = 0
+	} else {
+		f.field++
+	}
+}
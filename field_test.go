@@ -0,0 +1,40 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFieldReplacer(t *testing.T) {
+	inner := NewReplacer("foo", "bar").(BytesReplacer)
+	fr := NewFieldReplacer(';', 2, inner)
+
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader("foo;foo;xfoo;foo"), fr)
+	res, _ := io.ReadAll(r)
+	if string(res) != "foo;foo;xbar;foo" {
+		t.Errorf("should foo;foo;xbar;foo but %s", res)
+	}
+
+	if index, _, _ := NewFieldReplacer(';', 2, inner).Index([]byte("foo;foo")); index != -1 {
+		t.Errorf("missing field should not match, got %d", index)
+	}
+}
+
+func TestFieldReplacer_SeveralMatches(t *testing.T) {
+	fr := NewFieldReplacer(';', 2, NewReplacer("foo", "bar").(BytesReplacer)).(Replacer)
+	for _, c := range []struct{ src, want string }{
+		{"a;b;foo;c;foo", "a;b;bar;c;foo"},
+		{"a;b;foofoo;c", "a;b;barbar;c"},
+		{"a;b;foo foo;foo\nfoo;foo;xfoofoo;foo", "a;b;bar bar;foo\nfoo;foo;xbarbar;foo"},
+		{strings.Repeat("foo;foo;foo;foo\n", 1000), strings.Repeat("foo;foo;bar;foo\n", 1000)},
+	} {
+		for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.OneByteReader} {
+			res, err := io.ReadAll(fr.Replace(wrap(strings.NewReader(c.src))))
+			if err != nil || string(res) != c.want {
+				t.Errorf("%.20q: should %.40q but %.40q %v", c.src, c.want, res, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package libio
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/eleztian/pipe/bytespool/ladder"
+	"io"
+)
+
+type filteringLineReader struct {
+	sc   *bufio.Scanner
+	keep func(line []byte) bool
+	buf  []byte // pool buffer given to sc
+	line []byte // kept line not delivered yet
+	err  error
+}
+
+// NewFilteringLineReader returns a reader of the lines of src for which keep
+// returns true, like grep. Lines are given to keep and delivered with their
+// newline, if any. Lines longer than bufio.MaxScanTokenSize make Read fail with
+// bufio.ErrTooLong.
+func NewFilteringLineReader(src io.Reader, keep func(line []byte) bool) io.Reader {
+	buf := ladder.Get(defaultBufSize)
+	sc := bufio.NewScanner(src)
+	sc.Buffer(buf, bufio.MaxScanTokenSize)
+	sc.Split(scanLinesWithNewline)
+	return &filteringLineReader{sc: sc, keep: keep, buf: buf}
+}
+
+// scanLinesWithNewline is bufio.ScanLines keeping the '\n' that ends the lines.
+func scanLinesWithNewline(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (f *filteringLineReader) Read(p []byte) (int, error) {
+	for len(f.line) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		if !f.sc.Scan() {
+			f.err = f.sc.Err()
+			if f.err == nil {
+				f.err = io.EOF
+			}
+			if f.buf != nil {
+				_ = ladder.Put(f.buf)
+				f.buf = nil
+			}
+			continue
+		}
+		if line := f.sc.Bytes(); f.keep(line) {
+			f.line = line
+		}
+	}
+	n := copy(p, f.line)
+	f.line = f.line[n:]
+	return n, nil
+}
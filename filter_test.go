@@ -0,0 +1,20 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFilteringLineReader(t *testing.T) {
+	src := "error: a\ninfo: b\nerror: c\r\ninfo: d\nerror: e"
+	r := NewFilteringLineReader(iotest.HalfReader(strings.NewReader(src)), func(line []byte) bool {
+		return bytes.HasPrefix(line, []byte("error"))
+	})
+	res, err := io.ReadAll(iotest.OneByteReader(r))
+	if want := "error: a\nerror: c\r\nerror: e"; err != nil || string(res) != want {
+		t.Errorf("should %q but %q %v", want, res, err)
+	}
+}
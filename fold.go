@@ -0,0 +1,59 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+)
+
+// foldReplace is a BytesReplacer matching search under simple Unicode case-folding.
+// Only windows of buf with the same byte length as search are considered.
+type foldReplace struct {
+	search  []byte
+	replace []byte
+}
+
+func (f *foldReplace) GetSizingHints() (int, int, float64) {
+	searchLen := len(f.search)
+	replaceLen := len(f.replace)
+	ratio := float64(-1)
+	if searchLen < replaceLen {
+		ratio = float64(searchLen) / float64(replaceLen)
+	}
+	return searchLen, replaceLen, ratio
+}
+
+func (f *foldReplace) Index(buf []byte) (int, []byte, []byte) {
+	index := foldIndex(buf, f.search)
+	if index < 0 {
+		return -1, nil, nil
+	}
+	return index, buf[index : index+len(f.search)], f.replace
+}
+
+func (f *foldReplace) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, f)
+}
+
+// foldIndex returns the index of the first window of buf that equals search under
+// case-folding, -1 if there is none.
+func foldIndex(buf, search []byte) int {
+	n := len(search)
+	if n == 0 {
+		return -1
+	}
+	first := search[0]
+	lower, upper := first, first
+	if first < 0x80 {
+		lower = bytes.ToLower(search[:1])[0]
+		upper = bytes.ToUpper(search[:1])[0]
+	}
+	for i := 0; i+n <= len(buf); i++ {
+		if first < 0x80 && buf[i] != lower && buf[i] != upper {
+			continue
+		}
+		if bytes.EqualFold(buf[i:i+n], search) {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,5 +1,8 @@
 module github.com/eleztian/pipe
 
-go 1.16
+go 1.18
 
-require golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
+require (
+	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
+	golang.org/x/text v0.3.6
+)
@@ -0,0 +1,112 @@
+package libio
+
+import (
+	"compress/gzip"
+	"github.com/eleztian/pipe/bytespool/ladder"
+	"io"
+)
+
+// GzipOption configures the reader returned by NewGzipReplacingReader.
+type GzipOption func(*gzipOptions)
+
+type gzipOptions struct {
+	level int
+}
+
+// WithGzipLevel sets the compression level of the output, gzip.DefaultCompression
+// by default.
+func WithGzipLevel(level int) GzipOption {
+	return func(o *gzipOptions) {
+		o.level = level
+	}
+}
+
+// NewGzipReplacingReader returns a reader of the gzip stream src with the tokens
+// of replacer replaced in its decompressed content, compressed again by
+// NewReplaceCompressingReader. The error is that of reading the gzip header of src
+// or of an invalid level.
+func NewGzipReplacingReader(src io.Reader, replacer Replacer, opts ...GzipOption) (io.ReadCloser, error) {
+	o := gzipOptions{level: gzip.DefaultCompression}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return nil, err
+	}
+	zw, err := gzip.NewWriterLevel(nil, o.level)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplaceCompressingReader(zr, replacer, func(w io.Writer) io.WriteCloser {
+		zw.Reset(w)
+		return zw
+	}), nil
+}
+
+// peekedReader is a source whose first bytes were read ahead into buf, a ladder
+// pool buffer given back once they are delivered.
+type peekedReader struct {
+	r   io.Reader
+	buf []byte // buf[off:] is left to deliver
+	off int
+}
+
+func (p *peekedReader) Read(b []byte) (int, error) {
+	if p.buf == nil {
+		return p.r.Read(b)
+	}
+	n := copy(b, p.buf[p.off:])
+	if p.off += n; p.off == len(p.buf) {
+		p.release()
+	}
+	return n, nil
+}
+
+func (p *peekedReader) release() {
+	if p.buf != nil {
+		_ = ladder.Put(p.buf[:cap(p.buf)])
+		p.buf = nil
+	}
+}
+
+type autoDecompressReader struct {
+	io.Reader
+	peeked *peekedReader
+	zr     *gzip.Reader // nil if the source is not gzip
+}
+
+// Close releases the resources of the reader; it does not close the source.
+func (a *autoDecompressReader) Close() error {
+	var err error
+	if a.zr != nil {
+		err = a.zr.Close()
+	}
+	a.peeked.release()
+	return err
+}
+
+// NewAutoDecompressReader returns a reader of the decompressed content of src if
+// it starts with the gzip magic number, or of src as is otherwise. The error is
+// that of reading the first bytes of src or the gzip header.
+func NewAutoDecompressReader(src io.Reader) (io.ReadCloser, error) {
+	buf := ladder.Get(2)
+	n, err := io.ReadFull(src, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		_ = ladder.Put(buf)
+		return nil, err
+	}
+	peeked := &peekedReader{r: src, buf: buf[:n]}
+	if n == 0 {
+		peeked.release()
+	}
+	if n < 2 || buf[0] != 0x1f || buf[1] != 0x8b {
+		return &autoDecompressReader{Reader: peeked, peeked: peeked}, nil
+	}
+	zr, err := gzip.NewReader(peeked)
+	if err != nil {
+		peeked.release()
+		return nil, err
+	}
+	return &autoDecompressReader{Reader: zr, peeked: peeked, zr: zr}, nil
+}
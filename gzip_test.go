@@ -0,0 +1,82 @@
+package libio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGzipReplacingReader(t *testing.T) {
+	content := strings.Repeat("hello foo world ", 1000)
+	var src bytes.Buffer
+	zw := gzip.NewWriter(&src)
+	zw.Write([]byte(content))
+	zw.Close()
+
+	r, err := NewGzipReplacingReader(&src, NewReplacer("foo", "bar"), WithGzipLevel(gzip.BestSpeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := io.ReadAll(zr)
+	if want := strings.ReplaceAll(content, "foo", "bar"); err != nil || string(res) != want {
+		t.Errorf("unexpected content of %d bytes %v", len(res), err)
+	}
+
+	if _, err = NewGzipReplacingReader(strings.NewReader("not gzip"), NewReplacer("a", "b")); err == nil {
+		t.Errorf("should fail on a non gzip source")
+	}
+}
+
+func TestGzipReplacingReader_Close(t *testing.T) {
+	var src bytes.Buffer
+	zw := gzip.NewWriter(&src)
+	zw.Write(bytes.Repeat([]byte("foo"), 1<<20))
+	zw.Close()
+
+	r, err := NewGzipReplacingReader(&src, NewReplacer("foo", "bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Read(make([]byte, 10))
+	r.Close()
+}
+
+func TestAutoDecompressReader(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("compressed"))
+	zw.Close()
+
+	for _, c := range []struct {
+		src  io.Reader
+		want string
+	}{
+		{&gz, "compressed"},
+		{strings.NewReader("plain text"), "plain text"},
+		{strings.NewReader("\x1f"), "\x1f"},
+		{strings.NewReader(""), ""},
+	} {
+		r, err := NewAutoDecompressReader(c.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := io.ReadAll(r)
+		if err != nil || string(res) != c.want {
+			t.Errorf("should %q but %q %v", c.want, res, err)
+		}
+		if err := r.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := NewAutoDecompressReader(strings.NewReader("\x1f\x8bnot gzip")); err == nil {
+		t.Errorf("should fail on an invalid gzip header")
+	}
+}
@@ -0,0 +1,41 @@
+package libio
+
+import (
+	"hash"
+	"io"
+)
+
+type hashAppendingReader struct {
+	r       io.Reader
+	h       hash.Hash
+	trailer []byte // left to deliver once src is exhausted
+	done    bool
+}
+
+// NewHashAppendingReader returns a reader of src followed by the hash of src
+// computed with h, which makes the stream self-verifying. h should be freshly
+// created or reset.
+func NewHashAppendingReader(src io.Reader, h hash.Hash) io.Reader {
+	return &hashAppendingReader{r: src, h: h}
+}
+
+func (r *hashAppendingReader) Read(p []byte) (int, error) {
+	if !r.done {
+		n, err := r.r.Read(p)
+		r.h.Write(p[:n])
+		if err != io.EOF {
+			return n, err
+		}
+		r.done = true
+		r.trailer = r.h.Sum(nil)
+		if n > 0 {
+			return n, nil
+		}
+	}
+	if len(r.trailer) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.trailer)
+	r.trailer = r.trailer[n:]
+	return n, nil
+}
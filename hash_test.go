@@ -0,0 +1,17 @@
+package libio
+
+import (
+	"crypto/sha256"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHashAppendingReader(t *testing.T) {
+	res, err := io.ReadAll(NewHashAppendingReader(iotest.OneByteReader(strings.NewReader("hello")), sha256.New()))
+	sum := sha256.Sum256([]byte("hello"))
+	if want := "hello" + string(sum[:]); err != nil || string(res) != want {
+		t.Errorf("should %x but %x %v", want, res, err)
+	}
+}
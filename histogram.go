@@ -0,0 +1,38 @@
+package libio
+
+import (
+	"io"
+	"sync"
+)
+
+// HistogramWriter is a writer forwarding the data to another one while counting
+// the occurrences of each byte value.
+type HistogramWriter struct {
+	dst    io.Writer
+	mu     sync.Mutex
+	counts [256]int64
+}
+
+// NewHistogramWriter returns a HistogramWriter writing to dst.
+func NewHistogramWriter(dst io.Writer) *HistogramWriter {
+	return &HistogramWriter{dst: dst}
+}
+
+// Write writes p to dst, counting the bytes dst accepted.
+func (h *HistogramWriter) Write(p []byte) (int, error) {
+	n, err := h.dst.Write(p)
+	h.mu.Lock()
+	for _, b := range p[:n] {
+		h.counts[b]++
+	}
+	h.mu.Unlock()
+	return n, err
+}
+
+// Histogram returns the number of bytes of each value written so far. It is safe
+// to call concurrently with Write.
+func (h *HistogramWriter) Histogram() [256]int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.counts
+}
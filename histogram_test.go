@@ -0,0 +1,24 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestHistogramWriter(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHistogramWriter(&buf)
+	if _, err := io.Copy(h, NewReplacer("a", "bb").Replace(strings.NewReader("abcab"))); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "bbbcbbb" {
+		t.Errorf("should write through but %q", buf.String())
+	}
+	var want [256]int64
+	want['b'], want['c'] = 6, 1
+	if got := h.Histogram(); got != want {
+		t.Errorf("should count 6 b and 1 c but %d b %d c", got['b'], got['c'])
+	}
+}
@@ -0,0 +1,35 @@
+package libio
+
+import "io"
+
+var (
+	htmlEscaper = NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		`"`, "&#34;",
+		"'", "&#39;",
+	)
+	htmlUnescaper = NewReplacer(
+		"&amp;", "&",
+		"&lt;", "<",
+		"&gt;", ">",
+		"&#34;", `"`,
+		"&quot;", `"`,
+		"&#39;", "'",
+		"&apos;", "'",
+	)
+)
+
+// NewHTMLEntityEscaper returns a reader of src with <, >, &, " and ' escaped, like
+// html.EscapeString does.
+func NewHTMLEntityEscaper(src io.Reader) io.Reader {
+	return htmlEscaper.Replace(src)
+}
+
+// NewHTMLEntityUnescaper returns a reader of src with the entities written by
+// NewHTMLEntityEscaper, as well as &quot; and &apos;, unescaped. Other entities are
+// left as they are.
+func NewHTMLEntityUnescaper(src io.Reader) io.Reader {
+	return htmlUnescaper.Replace(src)
+}
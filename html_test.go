@@ -0,0 +1,22 @@
+package libio
+
+import (
+	"html"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHTMLEntityEscaper(t *testing.T) {
+	src := `<a href="x?a=1&b='2'">&amp;</a>`
+	res, _ := io.ReadAll(NewHTMLEntityEscaper(iotest.OneByteReader(strings.NewReader(src))))
+	if want := html.EscapeString(src); string(res) != want {
+		t.Fatalf("should %s but %s", want, res)
+	}
+
+	res, _ = io.ReadAll(NewHTMLEntityUnescaper(iotest.OneByteReader(strings.NewReader(string(res)))))
+	if string(res) != src {
+		t.Errorf("should %s but %s", src, res)
+	}
+}
@@ -0,0 +1,71 @@
+package libio
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ReplacingResponseWriter is an http.ResponseWriter replacing tokens in the body
+// written to it before passing it on.
+type ReplacingResponseWriter struct {
+	w           http.ResponseWriter
+	wc          io.WriteCloser
+	wroteHeader bool
+}
+
+// NewReplacingResponseWriter returns a writer replacing tokens with replacer in the
+// body written to w. Only the bytes that might be the beginning of a search token
+// are held back, the rest is written to w as it comes, so streamed responses are
+// not buffered. Close must be called once the handler is done to write the bytes
+// held back. As replacements change the length of the body, a Content-Length
+// header is removed. replacer must be built on StreamReplacingReader, as the
+// replacers of this package are.
+func NewReplacingResponseWriter(w http.ResponseWriter, replacer Replacer) *ReplacingResponseWriter {
+	br, ok := bytesReplacerOf(replacer)
+	if !ok {
+		panic("libio.NewReplacingResponseWriter: replacer is not based on StreamReplacingReader")
+	}
+	return &ReplacingResponseWriter{w: w, wc: NewReplacingWriter(w, br)}
+}
+
+func (rw *ReplacingResponseWriter) Header() http.Header {
+	return rw.w.Header()
+}
+
+func (rw *ReplacingResponseWriter) WriteHeader(statusCode int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.w.Header().Del("Content-Length")
+	rw.w.WriteHeader(statusCode)
+}
+
+func (rw *ReplacingResponseWriter) Write(p []byte) (int, error) {
+	rw.WriteHeader(http.StatusOK)
+	return rw.wc.Write(p)
+}
+
+// Flush flushes the wrapped writer if it is an http.Flusher. The bytes held back as
+// the possible beginning of a search token are not flushed.
+func (rw *ReplacingResponseWriter) Flush() {
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hijacks the connection of the wrapped writer if it is an http.Hijacker.
+func (rw *ReplacingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := rw.w.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("libio.ReplacingResponseWriter: wrapped writer is not a http.Hijacker")
+}
+
+// Close writes the bytes held back to the wrapped writer.
+func (rw *ReplacingResponseWriter) Close() error {
+	return rw.wc.Close()
+}
@@ -0,0 +1,28 @@
+package libio
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReplacingResponseWriter(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := NewReplacingResponseWriter(w, NewReplacer("foo", "bar!"))
+		defer rw.Close()
+		rw.Header().Set("Content-Length", "15")
+		io.WriteString(rw, "<p>fo")
+		rw.Flush()
+		io.WriteString(rw, "o</p><b>foo")
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != "<p>bar!</p><b>bar!" || rec.Header().Get("Content-Length") != "" || !rec.Flushed {
+		t.Errorf("unexpected response %q %v", rec.Body.String(), rec.Header())
+	}
+
+	if _, _, err := NewReplacingResponseWriter(rec, NewReplacer("a", "b")).Hijack(); err == nil {
+		t.Errorf("should fail to hijack a recorder")
+	}
+}
@@ -0,0 +1,115 @@
+package libio
+
+import (
+	"errors"
+	"github.com/eleztian/pipe/bytespool/ladder"
+	"io"
+	"sync"
+	"time"
+)
+
+// WithMaxLatency makes the reader deliver the bytes it holds back as the possible
+// beginning of a search token, unmodified, once the source has not returned data
+// for d. A token completed by data arriving later is then not replaced. The source
+// is read ahead by a goroutine, which Close stops.
+func WithMaxLatency(d time.Duration) ReaderOption {
+	return func(r *StreamReplacingReader) {
+		r.maxLatency = d
+	}
+}
+
+// errFlush is returned by latencySource when no data was read within the latency.
+var errFlush = errors.New("max latency exceeded")
+
+type latencyChunk struct {
+	buf []byte
+	n   int
+	err error
+}
+
+// latencySource reads its source in a goroutine so that Read can give up waiting.
+type latencySource struct {
+	ch    chan latencyChunk
+	done  chan struct{}
+	once  sync.Once
+	cur   latencyChunk
+	off   int
+	timer *time.Timer
+}
+
+func newLatencySource(src io.Reader) *latencySource {
+	ls := &latencySource{
+		ch:   make(chan latencyChunk, 1),
+		done: make(chan struct{}),
+	}
+	go ls.fetch(src)
+	return ls
+}
+
+func (ls *latencySource) fetch(src io.Reader) {
+	for {
+		buf := ladder.Get(defaultBufSize)
+		if buf == nil {
+			buf = make([]byte, defaultBufSize)
+		}
+		n, err := src.Read(buf)
+		select {
+		case ls.ch <- latencyChunk{buf: buf, n: n, err: err}:
+		case <-ls.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// read is like Read but returns errFlush if no data is read within timeout, if
+// positive.
+func (ls *latencySource) read(p []byte, timeout time.Duration) (int, error) {
+	if ls.cur.buf == nil || ls.off == ls.cur.n {
+		if ls.cur.err != nil {
+			return 0, ls.cur.err
+		}
+		if ls.cur.buf != nil {
+			_ = ladder.Put(ls.cur.buf)
+			ls.cur.buf = nil
+		}
+		var expired <-chan time.Time
+		if timeout > 0 {
+			if ls.timer == nil {
+				ls.timer = time.NewTimer(timeout)
+			} else {
+				ls.timer.Reset(timeout)
+			}
+			expired = ls.timer.C
+		}
+		select {
+		case c := <-ls.ch:
+			if timeout > 0 && !ls.timer.Stop() {
+				<-ls.timer.C
+			}
+			ls.cur, ls.off = c, 0
+		case <-expired:
+			return 0, errFlush
+		case <-ls.done:
+			return 0, io.ErrClosedPipe
+		}
+	}
+	n := copy(p, ls.cur.buf[ls.off:ls.cur.n])
+	ls.off += n
+	if ls.off == ls.cur.n && ls.cur.err != nil {
+		return n, ls.cur.err
+	}
+	return n, nil
+}
+
+func (ls *latencySource) Read(p []byte) (int, error) {
+	return ls.read(p, 0)
+}
+
+func (ls *latencySource) close() {
+	ls.once.Do(func() {
+		close(ls.done)
+	})
+}
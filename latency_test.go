@@ -0,0 +1,36 @@
+package libio
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestStreamReplacingReader_WithMaxLatency(t *testing.T) {
+	pr, pw := io.Pipe()
+	r := NewStreamReplacingReader(pr, NewReplacer("foobar", "x").(BytesReplacer), WithMaxLatency(20*time.Millisecond))
+	defer r.Close()
+
+	go pw.Write([]byte("a foo"))
+	buf := make([]byte, 16)
+	var res []byte
+	for len(res) < 5 {
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, buf[:n]...)
+	}
+	if string(res) != "a foo" {
+		t.Fatalf("should a foo but %s", res)
+	}
+
+	go func() {
+		pw.Write([]byte("bar foobar"))
+		pw.Close()
+	}()
+	rest, err := io.ReadAll(r)
+	if err != nil || string(rest) != "bar x" {
+		t.Errorf("should bar x but %s %v", rest, err)
+	}
+}
@@ -0,0 +1,67 @@
+package libiotest
+
+import (
+	"bytes"
+	libio "github.com/eleztian/pipe"
+	"io"
+	"testing"
+)
+
+// VerifyGetSizingHints replaces each of inputs with r through a
+// libio.StreamReplacingReader and reports an error on t for every match found by r
+// that is not covered by the values returned by its GetSizingHints: a search or
+// replace token longer than the maximums, or a replacement growing the data more
+// than the minimum search over replace ratio allows.
+func VerifyGetSizingHints(t testing.TB, r libio.BytesReplacer, inputs [][]byte) {
+	t.Helper()
+	maxSearch, maxReplace, ratio := r.GetSizingHints()
+	h := &hintsChecker{BytesReplacer: r, t: t, maxSearch: maxSearch, maxReplace: maxReplace, ratio: ratio}
+	for i, input := range inputs {
+		h.input = i
+		if _, err := io.Copy(io.Discard, libio.NewStreamReplacingReader(bytes.NewReader(input), h)); err != nil {
+			t.Errorf("input %d: %v", i, err)
+		}
+	}
+}
+
+// hintsChecker checks every match of the wrapped BytesReplacer against its hints.
+type hintsChecker struct {
+	libio.BytesReplacer
+	t          testing.TB
+	maxSearch  int
+	maxReplace int
+	ratio      float64
+	input      int
+}
+
+func (h *hintsChecker) SetOffset(offset int64) {
+	if os, ok := h.BytesReplacer.(libio.OffsetSetter); ok {
+		os.SetOffset(offset)
+	}
+}
+
+func (h *hintsChecker) Index(buf []byte) (int, []byte, []byte) {
+	index, search, replace := h.BytesReplacer.Index(buf)
+	if index < 0 {
+		return index, search, replace
+	}
+	if len(search) > h.maxSearch {
+		h.t.Errorf("input %d: search token %q is longer than the max search token len %d", h.input, search, h.maxSearch)
+	}
+	if len(replace) > h.maxReplace {
+		h.t.Errorf("input %d: replace token %q is longer than the max replace token len %d", h.input, replace, h.maxReplace)
+	}
+	if len(search) < len(replace) {
+		if ratio := float64(len(search)) / float64(len(replace)); h.ratio <= 0 || ratio < h.ratio {
+			h.t.Errorf("input %d: replacing %q with %q has a search over replace ratio %g below the hint %g",
+				h.input, search, replace, ratio, h.ratio)
+		}
+	}
+	return index, search, replace
+}
+
+func (h *hintsChecker) Matched(offset int64, search, replace []byte) {
+	if mo, ok := h.BytesReplacer.(libio.MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+}
@@ -0,0 +1,40 @@
+package libiotest
+
+import (
+	libio "github.com/eleztian/pipe"
+	"testing"
+)
+
+// wrongHints reports the hints of its BytesReplacer with the ratio left out.
+type wrongHints struct {
+	libio.BytesReplacer
+}
+
+func (w wrongHints) GetSizingHints() (int, int, float64) {
+	maxSearch, maxReplace, _ := w.BytesReplacer.GetSizingHints()
+	return maxSearch, maxReplace, -1
+}
+
+// recordingTB records the errors reported instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors int
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors++
+}
+
+func TestVerifyGetSizingHints(t *testing.T) {
+	inputs := [][]byte{[]byte("a foo b"), []byte("foofoo"), nil}
+	r := libio.NewReplacer("foo", "longer bar", "b", "").(libio.BytesReplacer)
+	VerifyGetSizingHints(t, r, inputs)
+
+	rec := &recordingTB{TB: t}
+	VerifyGetSizingHints(rec, wrongHints{r}, inputs)
+	if rec.errors == 0 {
+		t.Errorf("should report the expansion not covered by the hints")
+	}
+}
@@ -0,0 +1,35 @@
+// Package libiotest implements utilities for testing code built on libio.
+package libiotest
+
+import (
+	libio "github.com/eleztian/pipe"
+	"io"
+	"strings"
+)
+
+type referenceReplacer struct {
+	r *strings.Replacer
+}
+
+// NewReferenceReplacer returns a Replacer that reads the whole source and replaces
+// it with strings.NewReplacer(oldnews...). It is meant as a reference to compare
+// the output of the streaming replacers with in tests.
+func NewReferenceReplacer(oldnews ...string) libio.Replacer {
+	return &referenceReplacer{r: strings.NewReplacer(oldnews...)}
+}
+
+func (r *referenceReplacer) Replace(src io.Reader) io.Reader {
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return io.MultiReader(strings.NewReader(r.r.Replace(string(b))), &errReader{err: err})
+	}
+	return strings.NewReader(r.r.Replace(string(b)))
+}
+
+type errReader struct {
+	err error
+}
+
+func (e *errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
@@ -0,0 +1,19 @@
+package libiotest
+
+import (
+	libio "github.com/eleztian/pipe"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReferenceReplacer(t *testing.T) {
+	oldnews := []string{"foo", "bar", "fo", "x", "o", "0"}
+	content := "foo fo o foofoo ofo"
+	want, _ := io.ReadAll(NewReferenceReplacer(oldnews...).Replace(strings.NewReader(content)))
+	res, _ := io.ReadAll(libio.NewReplacer(oldnews...).Replace(iotest.OneByteReader(strings.NewReader(content))))
+	if string(res) != string(want) {
+		t.Errorf("should %s but %s", want, res)
+	}
+}
@@ -0,0 +1,95 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+)
+
+// NewLineAlignedReplacingReader replaces tokens of src with replacer like
+// StreamReplacingReader, but only makes output available in whole lines: data is
+// buffered until a '\n' is seen, except for the last line of the stream. Long
+// lines are buffered entirely.
+func NewLineAlignedReplacingReader(src io.Reader, replacer BytesReplacer) io.Reader {
+	return &lineAlignedReader{
+		r:   (&StreamReplacingReader{}).ResetEx(src, replacer),
+		buf: make([]byte, 0, defaultBufSize),
+	}
+}
+
+type lineAlignedReader struct {
+	r     io.Reader
+	buf   []byte
+	ready int // buf[:ready] ends with a '\n' and can be delivered
+	err   error
+}
+
+func (l *lineAlignedReader) Read(p []byte) (int, error) {
+	for l.ready == 0 {
+		if l.err != nil {
+			if len(l.buf) == 0 {
+				return 0, l.err
+			}
+			l.ready = len(l.buf)
+			break
+		}
+		if len(l.buf) == cap(l.buf) {
+			l.buf = append(l.buf, 0)[:len(l.buf)]
+		}
+		start := len(l.buf)
+		var n int
+		n, l.err = l.r.Read(l.buf[start:cap(l.buf)])
+		l.buf = l.buf[:start+n]
+		if i := bytes.LastIndexByte(l.buf[start:], '\n'); i >= 0 {
+			l.ready = start + i + 1
+		}
+	}
+
+	n := copy(p, l.buf[:l.ready])
+	l.buf = l.buf[:copy(l.buf, l.buf[n:])]
+	l.ready -= n
+	return n, nil
+}
+
+// NewLineSuffixAppender returns a reader of src with suffix inserted before every
+// '\n', e.g. "\r" to convert line endings from LF to CRLF.
+func NewLineSuffixAppender(src io.Reader, suffix []byte) io.Reader {
+	replace := make([]byte, 0, len(suffix)+1)
+	replace = append(append(replace, suffix...), '\n')
+	return (&StreamReplacingReader{}).ResetEx(src, &byteReplace{search: []byte{'\n'}, replace: replace})
+}
+
+// NewLineWidthPadder returns a reader of src in which every line is made exactly
+// width bytes long, not counting the '\n', by appending pad bytes to shorter lines
+// and truncating longer ones. A last line without '\n' is also padded, unless
+// empty.
+func NewLineWidthPadder(src io.Reader, width int, pad byte) io.Reader {
+	if width < 0 {
+		panic("libio.NewLineWidthPadder: width cannot be negative")
+	}
+	col := 0 // bytes of the current line read so far
+	padLine := func(dst []byte) []byte {
+		for ; col < width; col++ {
+			dst = append(dst, pad)
+		}
+		return dst
+	}
+	return newTransformReader(src, func(dst, p []byte) []byte {
+		for _, b := range p {
+			if b == '\n' {
+				dst = append(padLine(dst), b)
+				col = 0
+				continue
+			}
+			if col < width {
+				dst = append(dst, b)
+			}
+			col++
+		}
+		return dst
+	}, func(dst []byte) []byte {
+		if col > 0 {
+			dst = padLine(dst)
+		}
+		return dst
+	})
+}
@@ -0,0 +1,57 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLineAlignedReplacingReader(t *testing.T) {
+	content := "foo one\nfoo two\nthree foo"
+	r := NewLineAlignedReplacingReader(iotest.HalfReader(strings.NewReader(content)), NewReplacer("foo", "bar").(BytesReplacer))
+
+	var reads []string
+	p := make([]byte, 64)
+	for {
+		n, err := r.Read(p)
+		if n > 0 {
+			reads = append(reads, string(p[:n]))
+		}
+		if err != nil {
+			break
+		}
+	}
+	for i, s := range reads[:len(reads)-1] {
+		if !strings.HasSuffix(s, "\n") {
+			t.Errorf("read %d %q should end with a newline", i, s)
+		}
+	}
+	if res := strings.Join(reads, ""); res != "bar one\nbar two\nthree bar" {
+		t.Errorf("unexpected result %q", res)
+	}
+}
+
+func TestLineSuffixAppender(t *testing.T) {
+	res, err := io.ReadAll(NewLineSuffixAppender(iotest.OneByteReader(strings.NewReader("a\nb\n\nc")), []byte("\r")))
+	if err != nil || string(res) != "a\r\nb\r\n\r\nc" {
+		t.Errorf("should convert to CRLF but %q %v", res, err)
+	}
+
+	// a source made of line feeds only expands the most.
+	src := strings.Repeat("\n", 3*defaultBufSize)
+	suffix := strings.Repeat("-", 100)
+	res, err = io.ReadAll(NewLineSuffixAppender(strings.NewReader(src), []byte(suffix)))
+	if want := strings.Repeat(suffix+"\n", 3*defaultBufSize); err != nil || string(res) != want {
+		t.Errorf("wrong output of %d bytes, %v", len(res), err)
+	}
+}
+
+func TestLineWidthPadder(t *testing.T) {
+	src := "ab\nabcdef\n\nabcd\nx"
+	want := "ab..\nabcd\n....\nabcd\nx..."
+	res, err := io.ReadAll(NewLineWidthPadder(iotest.OneByteReader(strings.NewReader(src)), 4, '.'))
+	if err != nil || string(res) != want {
+		t.Errorf("should %q but %q %v", want, res, err)
+	}
+}
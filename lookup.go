@@ -0,0 +1,40 @@
+package libio
+
+import "io"
+
+// NewLookupTableReader returns a reader of src in which every byte b is replaced
+// with table[b]. An identity table returns src itself.
+func NewLookupTableReader(src io.Reader, table [256]byte) io.Reader {
+	identity := true
+	for i, b := range table {
+		if b != byte(i) {
+			identity = false
+			break
+		}
+	}
+	if identity {
+		return src
+	}
+	return newTransformReader(src, func(dst, p []byte) []byte {
+		// p is the pooled read buffer, it is not read again before being delivered.
+		for i, b := range p {
+			p[i] = table[b]
+		}
+		return p
+	}, nil)
+}
+
+// NewChainedLookupReader returns a reader of src with the tables applied in turn,
+// the output of a table being the input of the next one. The tables are composed
+// into a single one first, so each byte is looked up once whatever their number.
+func NewChainedLookupReader(src io.Reader, tables ...[256]byte) io.Reader {
+	var table [256]byte
+	for i := range table {
+		b := byte(i)
+		for _, t := range tables {
+			b = t[b]
+		}
+		table[i] = b
+	}
+	return NewLookupTableReader(src, table)
+}
@@ -0,0 +1,45 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLookupTableReader(t *testing.T) {
+	var table [256]byte
+	for i := range table {
+		table[i] = byte(i)
+	}
+	src := strings.NewReader("abc")
+	if NewLookupTableReader(src, table) != io.Reader(src) {
+		t.Errorf("identity table should return src")
+	}
+
+	table['a'], table['b'] = 'b', 'a'
+	res, err := io.ReadAll(NewLookupTableReader(iotest.OneByteReader(strings.NewReader("abcab")), table))
+	if err != nil || string(res) != "bacba" {
+		t.Errorf("should %q but %q %v", "bacba", res, err)
+	}
+}
+
+func TestChainedLookupReader(t *testing.T) {
+	var swap, upper [256]byte
+	for i := range swap {
+		swap[i], upper[i] = byte(i), byte(i)
+	}
+	swap['a'], swap['b'] = 'b', 'a'
+	for c := 'a'; c <= 'z'; c++ {
+		upper[c] = byte(c - 'a' + 'A')
+	}
+
+	res, err := io.ReadAll(NewChainedLookupReader(strings.NewReader("abc"), swap, upper))
+	if err != nil || string(res) != "BAC" {
+		t.Errorf("should %q but %q %v", "BAC", res, err)
+	}
+	src := strings.NewReader("abc")
+	if NewChainedLookupReader(src, swap, swap) != io.Reader(src) {
+		t.Errorf("tables composing to the identity should return src")
+	}
+}
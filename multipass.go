@@ -0,0 +1,86 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+)
+
+type multiPassReplacer struct {
+	replacer  BytesReplacer
+	maxPasses int
+}
+
+// NewMultiPassReplacer returns a Replacer that applies replacer up to maxPasses
+// times, so that search tokens produced by a replacement are replaced as well.
+// replacer must not keep per stream state, it is shared by the passes.
+func NewMultiPassReplacer(replacer BytesReplacer, maxPasses int) Replacer {
+	if maxPasses <= 0 {
+		panic("libio.NewMultiPassReplacer: max passes must be positive")
+	}
+	return &multiPassReplacer{replacer: replacer, maxPasses: maxPasses}
+}
+
+func (m *multiPassReplacer) Replace(src io.Reader) io.Reader {
+	maxSearchTokenLen, _, _ := m.replacer.GetSizingHints()
+	return &multiPassReader{
+		replacer: m.replacer,
+		top:      (&StreamReplacingReader{}).ResetEx(src, m.replacer),
+		left:     m.maxPasses - 1,
+		tokenLen: maxSearchTokenLen,
+	}
+}
+
+type multiPassReader struct {
+	replacer BytesReplacer
+	top      *StreamReplacingReader // the last pass started
+	left     int                    // passes that can still be started
+	tokenLen int
+	buf      []byte // read from top but not delivered, the last bytes are held back
+	err      error
+}
+
+func (m *multiPassReader) Read(p []byte) (int, error) {
+	for {
+		if m.left == 0 {
+			if len(m.buf) == 0 {
+				return m.top.Read(p)
+			}
+			n := copy(p, m.buf)
+			m.buf = m.buf[n:]
+			return n, nil
+		}
+
+		// a token of each next pass can start up to tokenLen-1 bytes before one of
+		// the previous pass.
+		hold := (m.tokenLen - 1) * m.left
+		if ready := len(m.buf) - hold; ready > 0 || (m.err != nil && len(m.buf) > 0) {
+			if m.err != nil {
+				ready = len(m.buf)
+			}
+			n := copy(p, m.buf[:ready])
+			m.buf = m.buf[n:]
+			return n, nil
+		}
+		if m.err != nil {
+			return 0, m.err
+		}
+
+		if cap(m.buf)-len(m.buf) < defaultBufSize {
+			m.buf = append(make([]byte, 0, len(m.buf)+defaultBufSize), m.buf...)
+		}
+		n, err := m.top.Read(m.buf[len(m.buf):cap(m.buf)])
+		m.buf = m.buf[:len(m.buf)+n]
+		m.err = err
+		if m.top.Telemetry().ReplacementsCount > 0 {
+			// the next pass starts with the held back bytes a token overlapping the
+			// replacement could start with.
+			src := io.MultiReader(bytes.NewReader(m.buf), m.top)
+			if m.err != nil {
+				src = bytes.NewReader(m.buf)
+			}
+			m.top = (&StreamReplacingReader{}).ResetEx(src, m.replacer)
+			m.left--
+			m.buf, m.err = nil, nil
+		}
+	}
+}
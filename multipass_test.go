@@ -0,0 +1,56 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMultiPassReplacer(t *testing.T) {
+	br := NewReplacer("{name}", "{first} {last}", "{first}", "John", "{last}", "Doe").(BytesReplacer)
+
+	res, _ := io.ReadAll(NewMultiPassReplacer(br, 1).Replace(strings.NewReader("hi {name}")))
+	if string(res) != "hi {first} {last}" {
+		t.Errorf("should %q but %q", "hi {first} {last}", res)
+	}
+
+	res, _ = io.ReadAll(NewMultiPassReplacer(br, 3).Replace(strings.NewReader("hi {name}")))
+	if string(res) != "hi John Doe" {
+		t.Errorf("should %q but %q", "hi John Doe", res)
+	}
+}
+
+func TestMultiPassReplacer_EarlyStop(t *testing.T) {
+	br := NewReplacer("{name}", "{first} {last}", "{first}", "John", "{last}", "Doe").(BytesReplacer)
+	content := strings.Repeat("hi {name} ", 1000)
+	r := NewMultiPassReplacer(br, 10).Replace(iotest.HalfReader(strings.NewReader(content))).(*multiPassReader)
+	res, err := io.ReadAll(r)
+	if want := strings.Repeat("hi John Doe ", 1000); err != nil || string(res) != want {
+		t.Fatalf("unexpected output of %d bytes %v", len(res), err)
+	}
+	if r.left != 7 {
+		t.Errorf("should stop after the third pass, the first without replacements, but %d passes are left", r.left)
+	}
+}
+
+func TestMultiPassReplacer_LatePasses(t *testing.T) {
+	// each pass shortens the run of a, the last replacements of a pass overlap the
+	// bytes the previous one delivered unchanged.
+	br := NewReplacer("ab", "b").(BytesReplacer)
+	for _, c := range []struct {
+		src, want string
+		passes    int
+	}{
+		{strings.Repeat("x", 5000) + "aaaab", strings.Repeat("x", 5000) + "b", 10},
+		{strings.Repeat("x", 5000) + "aaaab" + strings.Repeat("y", 5000), strings.Repeat("x", 5000) + "ab" + strings.Repeat("y", 5000), 3},
+		{"xyz", "xyz", 3},
+	} {
+		for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.OneByteReader, iotest.HalfReader} {
+			res, err := io.ReadAll(NewMultiPassReplacer(br, c.passes).Replace(wrap(strings.NewReader(c.src))))
+			if err != nil || string(res) != c.want {
+				t.Errorf("should %.10q... of %d bytes but %d bytes %v", c.want, len(c.want), len(res), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package libio
+
+import (
+	"bytes"
+	"golang.org/x/text/unicode/norm"
+	"io"
+)
+
+// ReplacerOption configures the Replacer returned by NewReplacerWithOptions.
+type ReplacerOption func(*replacerOptions)
+
+type replacerOptions struct {
+	norm     bool
+	normForm norm.Form
+}
+
+// WithUnicodeNorm makes the search tokens match any text that is equal to them in
+// the given normal form, so that e.g. a precomposed "é" also matches "e" followed
+// by a combining acute accent. Both the search tokens and the scanned text are
+// normalized for the comparison only; the replacement is written as given.
+func WithUnicodeNorm(form norm.Form) ReplacerOption {
+	return func(o *replacerOptions) {
+		o.norm = true
+		o.normForm = form
+	}
+}
+
+// NewReplacerWithOptions is like NewReplacer but takes the old, new pairs as a
+// slice followed by options.
+func NewReplacerWithOptions(oldnews []string, opts ...ReplacerOption) Replacer {
+	var o replacerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	res := &replacer{
+		replaces: make([]BytesReplacer, 0),
+		minRatio: -1,
+	}
+
+	if len(oldnews)%2 == 1 {
+		panic("stream.NewReplacer: odd argument count")
+	}
+
+	for i := 0; i < len(oldnews); i += 2 {
+		if len(oldnews[i]) == 0 { // search can not be empty
+			continue
+		}
+		if o.norm {
+			res.add(newNormReplace(o.normForm, oldnews[i], oldnews[i+1]))
+			continue
+		}
+		res.add(&byteReplace{search: []byte(oldnews[i]), replace: []byte(oldnews[i+1])})
+	}
+
+	return res
+}
+
+// normReplace is a BytesReplacer matching search after normalizing both search
+// and the scanned buffer to form.
+type normReplace struct {
+	form    norm.Form
+	search  []byte // normalized search token
+	replace []byte
+	minLen  int // bounds of the length of a match in the source
+	maxLen  int
+}
+
+func newNormReplace(form norm.Form, search, replace string) *normReplace {
+	ns := form.Bytes([]byte(search))
+	// a source segment shrinks at most 3 times when composed, while a compatibility
+	// decomposition can grow it up to 18 times.
+	grow := 3
+	if form == norm.NFKC || form == norm.NFKD {
+		grow = 18
+	}
+	return &normReplace{
+		form:    form,
+		search:  ns,
+		replace: []byte(replace),
+		minLen:  max(1, len(ns)/grow),
+		// the segment following a match must be visible to know the match is complete.
+		maxLen: len(ns)*3 + norm.MaxSegmentSize,
+	}
+}
+
+func (n *normReplace) GetSizingHints() (int, int, float64) {
+	replaceLen := len(n.replace)
+	ratio := float64(-1)
+	if n.minLen < replaceLen {
+		ratio = float64(n.minLen) / float64(replaceLen)
+	}
+	return n.maxLen, replaceLen, ratio
+}
+
+func (n *normReplace) Index(buf []byte) (int, []byte, []byte) {
+	var it norm.Iter
+	for i := 0; i < len(buf); i++ {
+		if n.form.FirstBoundary(buf[i:]) != 0 {
+			continue
+		}
+		if l := n.matchAt(&it, buf[i:]); l > 0 {
+			return i, buf[i : i+l], n.replace
+		}
+	}
+	return -1, nil, nil
+}
+
+// matchAt returns the length of the prefix of buf that normalizes to the search
+// token, 0 if there is none.
+func (n *normReplace) matchAt(it *norm.Iter, buf []byte) int {
+	it.Init(n.form, buf)
+	got := 0
+	for !it.Done() {
+		seg := it.Next()
+		if got+len(seg) > len(n.search) || !bytes.Equal(seg, n.search[got:got+len(seg)]) {
+			return 0
+		}
+		got += len(seg)
+		if got == len(n.search) {
+			return it.Pos()
+		}
+	}
+	return 0
+}
+
+func (n *normReplace) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, n)
+}
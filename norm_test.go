@@ -0,0 +1,29 @@
+package libio
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReplacerWithUnicodeNorm(t *testing.T) {
+	// precomposed and decomposed "café", followed by "cafe" with an accent of its own.
+	src := "café café cafè cafe"
+	want := "tea tea cafè cafe"
+	for _, form := range []norm.Form{norm.NFC, norm.NFD} {
+		r := NewReplacerWithOptions([]string{"café", "tea"}, WithUnicodeNorm(form))
+		res, _ := io.ReadAll(r.Replace(iotest.OneByteReader(strings.NewReader(src))))
+		if string(res) != want {
+			t.Errorf("form %v: should %q but %q", form, want, res)
+		}
+	}
+
+	// a plain e must not match the start of an accented one.
+	r := NewReplacerWithOptions([]string{"e", "E"}, WithUnicodeNorm(norm.NFD))
+	res, _ := io.ReadAll(r.Replace(strings.NewReader("ée")))
+	if string(res) != "éE" {
+		t.Errorf("should %q but %q", "éE", res)
+	}
+}
@@ -0,0 +1,61 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+)
+
+type nthReplacer struct {
+	search  []byte
+	replace []byte
+	n       int
+}
+
+// NewNthOccurrenceReplacer returns a Replacer that only replaces the nth
+// occurrence of search in each stream, n = 1 being the first one. Occurrences
+// are counted like ReplacerBuilder.WithLimit counts replacements, without
+// overlaps. The occurrences before the nth one are replaced by themselves, so they
+// count in the ReplacementsCount telemetry.
+func NewNthOccurrenceReplacer(search, replace string, n int) Replacer {
+	if len(search) == 0 {
+		panic("libio.NewNthOccurrenceReplacer: search token cannot be empty")
+	}
+	if n <= 0 {
+		panic("libio.NewNthOccurrenceReplacer: n must be positive")
+	}
+	return &nthReplacer{search: []byte(search), replace: []byte(replace), n: n}
+}
+
+func (r *nthReplacer) newBytesReplacer() BytesReplacer {
+	return &nthIndex{byteReplace: byteReplace{search: r.search, replace: r.replace}, left: r.n}
+}
+
+func (r *nthReplacer) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, r.newBytesReplacer())
+}
+
+// nthIndex holds the per stream count of a nthReplacer.
+type nthIndex struct {
+	byteReplace
+	left int // occurrences until the one to replace, 0 once replaced
+}
+
+func (n *nthIndex) Index(buf []byte) (int, []byte, []byte) {
+	if n.left <= 0 {
+		return -1, nil, nil
+	}
+	index := bytes.Index(buf, n.search)
+	if n.left > 1 {
+		return index, n.search, n.search
+	}
+	return index, n.search, n.replace
+}
+
+func (n *nthIndex) Matched(offset int64, search, replace []byte) {
+	n.left--
+}
+
+func (n *nthIndex) clone() (BytesReplacer, bool) {
+	c := *n
+	return &c, true
+}
@@ -0,0 +1,18 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNthOccurrenceReplacer(t *testing.T) {
+	r := NewNthOccurrenceReplacer("foo", "bar", 2)
+	for i := 0; i < 2; i++ {
+		res, _ := io.ReadAll(r.Replace(iotest.OneByteReader(strings.NewReader("foo foo foo"))))
+		if string(res) != "foo bar foo" {
+			t.Errorf("should foo bar foo but %s", res)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package libio
+
+import "sync/atomic"
+
+// OffsetAwareReplacer wraps a BytesReplacer and records the source offset of the
+// last match replaced. It relies on StreamReplacingReader reporting matches through
+// MatchObserver, so it must be handed to the reader directly or within composites
+// of this package, and it tracks a single stream at a time.
+type OffsetAwareReplacer struct {
+	lastMatch int64 // updated atomically
+	inner     BytesReplacer
+}
+
+func NewOffsetAwareReplacer(inner BytesReplacer) *OffsetAwareReplacer {
+	return &OffsetAwareReplacer{lastMatch: -1, inner: inner}
+}
+
+func (o *OffsetAwareReplacer) GetSizingHints() (int, int, float64) {
+	return o.inner.GetSizingHints()
+}
+
+func (o *OffsetAwareReplacer) SetOffset(offset int64) {
+	if os, ok := o.inner.(OffsetSetter); ok {
+		os.SetOffset(offset)
+	}
+}
+
+func (o *OffsetAwareReplacer) Index(buf []byte) (int, []byte, []byte) {
+	return o.inner.Index(buf)
+}
+
+func (o *OffsetAwareReplacer) Matched(offset int64, search, replace []byte) {
+	atomic.StoreInt64(&o.lastMatch, offset)
+	if mo, ok := o.inner.(MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+}
+
+// LastMatchOffset returns the source offset of the last match, -1 if none.
+func (o *OffsetAwareReplacer) LastMatchOffset() int64 {
+	return atomic.LoadInt64(&o.lastMatch)
+}
@@ -0,0 +1,27 @@
+package libio
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOffsetAwareReplacer(t *testing.T) {
+	content := strings.Repeat("x", 5000) + "foo" + strings.Repeat("y", 7000) + "foo" + "zz"
+	or := NewOffsetAwareReplacer(NewReplacer("foo", "barbaz").(BytesReplacer))
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader(content), or)
+
+	var offsets []int64
+	p := make([]byte, 7)
+	for {
+		_, err := r.Read(p)
+		if off := or.LastMatchOffset(); len(offsets) == 0 || offsets[len(offsets)-1] != off {
+			offsets = append(offsets, off)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if len(offsets) != 3 || offsets[0] != -1 || offsets[1] != 5000 || offsets[2] != 12003 {
+		t.Errorf("unexpected match offsets %v", offsets)
+	}
+}
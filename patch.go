@@ -0,0 +1,89 @@
+package libio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Patch is an edit of a stream: Delete bytes at the source offset Offset are
+// replaced with Insert.
+type Patch struct {
+	Offset int64
+	Delete int
+	Insert []byte
+}
+
+var errPatchBeyondEOF = errors.New("libio: patch beyond the end of the source")
+
+// NewPatchingReader returns a reader of src with the patches applied as the
+// source goes past their offsets. Patches must be sorted by offset and must not
+// overlap; several insertions at the same offset are applied in order. The error
+// describes the first invalid patch. Reading fails if the source ends before a
+// patch could be applied.
+func NewPatchingReader(src io.Reader, patches []Patch) (io.Reader, error) {
+	var end int64 // end in the source of the previous patch
+	for i, p := range patches {
+		if p.Offset < 0 || p.Delete < 0 {
+			return nil, fmt.Errorf("libio: patch %d has a negative offset or length", i)
+		}
+		if p.Offset < end {
+			return nil, fmt.Errorf("libio: patch %d at offset %d overlaps or precedes the previous one ending at %d", i, p.Offset, end)
+		}
+		end = p.Offset + int64(p.Delete)
+	}
+	return &patchingReader{r: src, patches: append([]Patch(nil), patches...)}, nil
+}
+
+type patchingReader struct {
+	r       io.Reader
+	patches []Patch // not applied yet
+	pos     int64   // source offset
+	insert  []byte  // left to insert by the current patch
+	delete  int     // left to delete by the current patch
+	err     error
+}
+
+func (pr *patchingReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for {
+		if len(pr.insert) > 0 {
+			n := copy(p, pr.insert)
+			pr.insert = pr.insert[n:]
+			return n, nil
+		}
+		if pr.delete > 0 && pr.err == nil {
+			// p is used as a scratch buffer for the deleted bytes.
+			n, err := pr.r.Read(p[:min(len(p), pr.delete)])
+			pr.pos += int64(n)
+			pr.delete -= n
+			pr.err = err
+			continue
+		}
+		if pr.delete == 0 && len(pr.patches) > 0 && pr.patches[0].Offset == pr.pos {
+			// applied even if the source is done, for the patches at its end.
+			pr.insert, pr.delete = pr.patches[0].Insert, pr.patches[0].Delete
+			pr.patches = pr.patches[1:]
+			continue
+		}
+		if pr.err != nil {
+			if pr.err == io.EOF && (pr.delete > 0 || len(pr.patches) > 0) {
+				pr.err = errPatchBeyondEOF
+			}
+			return 0, pr.err
+		}
+
+		limit := len(p)
+		if len(pr.patches) > 0 {
+			limit = int(min64(int64(limit), pr.patches[0].Offset-pr.pos))
+		}
+		n, err := pr.r.Read(p[:limit])
+		pr.pos += int64(n)
+		pr.err = err
+		if n > 0 || err == nil {
+			return n, nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPatchingReader(t *testing.T) {
+	patches := []Patch{
+		{Offset: 0, Insert: []byte(">")},
+		{Offset: 4, Delete: 5, Insert: []byte("brown")},
+		{Offset: 9, Insert: []byte(" fox")},
+		{Offset: 9, Insert: []byte("!")},
+		{Offset: 13, Delete: 3},
+	}
+	src := "the quick dog !!"
+	for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.OneByteReader, iotest.DataErrReader} {
+		r, err := NewPatchingReader(wrap(strings.NewReader(src)), patches)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := io.ReadAll(r)
+		if want := ">the brown fox! dog"; err != nil || string(res) != want {
+			t.Errorf("should %q but %q %v", want, res, err)
+		}
+	}
+
+	if _, err := NewPatchingReader(strings.NewReader(src), []Patch{{Offset: 4, Delete: 2}, {Offset: 5}}); err == nil {
+		t.Errorf("should reject overlapping patches")
+	}
+	for _, c := range []struct {
+		patches []Patch
+		want    string
+		err     error
+	}{
+		{[]Patch{{Offset: 3, Insert: []byte("X")}}, "abcX", nil},
+		{[]Patch{{Offset: 0, Delete: 3}, {Offset: 3, Insert: []byte("X")}}, "X", nil},
+		{[]Patch{{Offset: 1, Delete: 3}}, "a", errPatchBeyondEOF},
+		{[]Patch{{Offset: 4, Insert: []byte("X")}}, "abc", errPatchBeyondEOF},
+	} {
+		// the patches at the end of the source apply whether or not the source
+		// returns io.EOF along with its last bytes.
+		for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.DataErrReader} {
+			r, _ := NewPatchingReader(wrap(strings.NewReader("abc")), c.patches)
+			res, err := io.ReadAll(r)
+			if string(res) != c.want || err != c.err {
+				t.Errorf("%v: should %q %v but %q %v", c.patches, c.want, c.err, res, err)
+			}
+		}
+	}
+
+	r, _ := NewPatchingReader(strings.NewReader(src), []Patch{{Offset: 100, Insert: []byte("x")}})
+	if _, err := io.ReadAll(r); err != errPatchBeyondEOF {
+		t.Errorf("should fail on a patch beyond the source but %v", err)
+	}
+}
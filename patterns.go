@@ -0,0 +1,56 @@
+package libio
+
+// PatternPair is a literal search/replace rule of a Replacer.
+type PatternPair struct {
+	Search  []byte
+	Replace []byte
+}
+
+// PatternLister is implemented by the Replacers that can list their rules, e.g.
+// to inspect, serialize or extend them: those returned by NewReplacer and its
+// variants, by NewCompositeReplacer and by ReplacerBuilder for literal rules, as
+// well as AtomicReplacer when its current replacer does. Rules that are not
+// literal, such as regex or case-insensitive ones, are not listed.
+type PatternLister interface {
+	Patterns() []PatternPair
+}
+
+// Patterns returns the literal rules of r, in the order they are matched on a tie.
+func (r *replacer) Patterns() []PatternPair {
+	var res []PatternPair
+	for _, br := range r.replaces {
+		switch rr := br.(type) {
+		case *byteReplace:
+			res = append(res, newPatternPair(rr.search, rr.replace))
+		case PatternLister:
+			res = append(res, rr.Patterns()...)
+		}
+	}
+	return res
+}
+
+// Patterns returns the rules of ac, in the order they are matched on a tie.
+func (ac *ahoCorasick) Patterns() []PatternPair {
+	res := make([]PatternPair, len(ac.searches))
+	for i := range ac.searches {
+		res[i] = newPatternPair(ac.searches[i], ac.replaces[i])
+	}
+	return res
+}
+
+// Patterns returns the rules of the current replacer, nil if it cannot list them.
+func (a *AtomicReplacer) Patterns() []PatternPair {
+	if pl, ok := a.Load().(PatternLister); ok {
+		return pl.Patterns()
+	}
+	return nil
+}
+
+// newPatternPair returns a pair with copies of search and replace, so that the
+// rules of the replacer cannot be changed through it.
+func newPatternPair(search, replace []byte) PatternPair {
+	return PatternPair{
+		Search:  append(make([]byte, 0, len(search)), search...),
+		Replace: append(make([]byte, 0, len(replace)), replace...),
+	}
+}
@@ -0,0 +1,27 @@
+package libio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatterns(t *testing.T) {
+	want := []PatternPair{
+		{Search: []byte("a"), Replace: []byte("1")},
+		{Search: []byte("b"), Replace: []byte("2")},
+		{Search: []byte("c"), Replace: []byte("")},
+	}
+
+	r := NewCompositeReplacer(NewReplacer("a", "1", "b", "2").(BytesReplacer), NewFieldReplacer(',', 0, NewReplacer("x", "y").(BytesReplacer)), NewReplacer("c", "").(BytesReplacer))
+	if got := r.(PatternLister).Patterns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("should %q but %q", want, got)
+	}
+
+	b, err := NewReplacerBuilder().AddLiteral("a", "1").AddLiteral("b", "2").AddLiteral("c", "").AddLiteral("d", "4").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := NewAtomicReplacer(b).Patterns(); !reflect.DeepEqual(got[:3], want) || len(got) != 4 {
+		t.Errorf("should %q but %q", want, got)
+	}
+}
@@ -0,0 +1,51 @@
+package libio
+
+import (
+	"io"
+	"sync"
+)
+
+// ReplacerPool recycles the StreamReplacingReaders of a Replacer, saving the
+// allocation of the reader and its buffer on every Replace call.
+type ReplacerPool struct {
+	replacer Replacer
+	shared   BytesReplacer // set if every stream can use the same BytesReplacer
+	pool     sync.Pool
+}
+
+// NewReplacerPool returns a pool of readers replacing like r, which must be built
+// on StreamReplacingReader, as the replacers of this package are.
+func NewReplacerPool(r Replacer) *ReplacerPool {
+	br, ok := bytesReplacerOf(r)
+	if !ok {
+		panic("libio.NewReplacerPool: replacer is not based on StreamReplacingReader")
+	}
+	p := &ReplacerPool{replacer: r}
+	switch r.(type) {
+	case streamBytesReplacer, *AtomicReplacer:
+	default:
+		p.shared = br
+	}
+	return p
+}
+
+// Get returns a reader of src, reusing a reader given back by Put if possible.
+func (p *ReplacerPool) Get(src io.Reader) *StreamReplacingReader {
+	sr, _ := p.pool.Get().(*StreamReplacingReader)
+	if sr != nil && p.shared != nil {
+		return sr.Reset(src)
+	}
+	if sr == nil {
+		sr = &StreamReplacingReader{}
+	}
+	// stateful and swappable replacers need a fresh BytesReplacer for each stream.
+	br, _ := bytesReplacerOf(p.replacer)
+	return sr.ResetEx(src, br)
+}
+
+// Put gives back a reader returned by Get once it is not used anymore.
+func (p *ReplacerPool) Put(sr *StreamReplacingReader) {
+	sr.r = nil
+	sr.err = nil
+	p.pool.Put(sr)
+}
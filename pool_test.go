@@ -0,0 +1,34 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReplacerPool(t *testing.T) {
+	p := NewReplacerPool(NewReplacer("foo", "bar"))
+	for _, src := range []string{"a foo", "foo foo b"} {
+		r := p.Get(strings.NewReader(src))
+		res, _ := io.ReadAll(r)
+		if want := strings.ReplaceAll(src, "foo", "bar"); string(res) != want {
+			t.Errorf("should %s but %s", want, res)
+		}
+		p.Put(r)
+	}
+
+	// the limit applies to each stream.
+	lr, err := NewReplacerBuilder().AddLiteral("foo", "bar").WithLimit(1).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lp := NewReplacerPool(lr)
+	for i := 0; i < 2; i++ {
+		r := lp.Get(strings.NewReader("foo foo"))
+		res, _ := io.ReadAll(r)
+		if string(res) != "bar foo" {
+			t.Errorf("should bar foo but %s", res)
+		}
+		lp.Put(r)
+	}
+}
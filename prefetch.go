@@ -0,0 +1,136 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool"
+	"io"
+	"sync"
+)
+
+// prefetchChunks is the number of chunks the prefetch goroutine reads ahead.
+const prefetchChunks = 4
+
+type prefetchChunk struct {
+	buf []byte
+	n   int
+	err error
+}
+
+type prefetchReader struct {
+	pool    bytespool.BytesPool
+	ch      chan prefetchChunk
+	done    chan struct{}
+	once    sync.Once
+	cur     prefetchChunk
+	off     int
+	readErr error
+}
+
+// NewPrefetchReader returns a reader of src that reads ahead in a goroutine into
+// up to prefetchChunks buffers of bufSize bytes taken from pool, so that src is
+// read while the consumer is busy. Close stops the goroutine once its current
+// Read of src returns; it does not close src.
+func NewPrefetchReader(src io.Reader, pool bytespool.BytesPool, bufSize int) io.ReadCloser {
+	if bufSize <= 0 {
+		panic("libio.NewPrefetchReader: buffer size must be positive")
+	}
+	p := &prefetchReader{
+		pool: pool,
+		ch:   make(chan prefetchChunk, prefetchChunks),
+		done: make(chan struct{}),
+	}
+	go p.fetch(src, bufSize)
+	return p
+}
+
+func (p *prefetchReader) fetch(src io.Reader, bufSize int) {
+	defer func() {
+		close(p.ch)
+		if p.closed() {
+			// give back the chunks sent after Close drained the queue.
+			for c := range p.ch {
+				_ = p.pool.Put(c.buf)
+			}
+		}
+	}()
+	for !p.closed() {
+		buf := p.pool.Get(bufSize)
+		if buf == nil {
+			buf = make([]byte, bufSize)
+		}
+		n, err := src.Read(buf)
+		if p.closed() {
+			_ = p.pool.Put(buf)
+			return
+		}
+		select {
+		case p.ch <- prefetchChunk{buf: buf, n: n, err: err}:
+		case <-p.done:
+			_ = p.pool.Put(buf)
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *prefetchReader) closed() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *prefetchReader) Read(b []byte) (int, error) {
+	if p.closed() {
+		if p.cur.buf != nil {
+			_ = p.pool.Put(p.cur.buf)
+			p.cur.buf = nil
+		}
+		return 0, io.ErrClosedPipe
+	}
+	for p.cur.buf == nil || p.off == p.cur.n {
+		if p.cur.buf != nil {
+			_ = p.pool.Put(p.cur.buf)
+			p.cur.buf = nil
+			if p.cur.err != nil {
+				p.readErr = p.cur.err
+			}
+		}
+		if p.readErr != nil {
+			return 0, p.readErr
+		}
+		c, ok := <-p.ch
+		if !ok {
+			return 0, io.ErrClosedPipe
+		}
+		p.cur, p.off = c, 0
+	}
+	n := copy(b, p.cur.buf[p.off:p.cur.n])
+	p.off += n
+	return n, nil
+}
+
+// Close stops prefetching and gives the buffers read ahead back to the pool,
+// except the one being read, given back by the next Read. It does not wait for
+// the goroutine, which gives back what it reads after Close when its pending
+// Read of src returns.
+func (p *prefetchReader) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+		for {
+			select {
+			case c, ok := <-p.ch:
+				if !ok {
+					return
+				}
+				_ = p.pool.Put(c.buf)
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,66 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool"
+	"github.com/eleztian/pipe/bytespool/fixed"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestPrefetchReader(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	pool := fixed.NewBytePool(prefetchChunks+1, 64)
+	r := NewPrefetchReader(iotest.HalfReader(strings.NewReader(content)), pool, 64)
+	res, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil || string(res) != content {
+		t.Fatalf("unexpected result %d %v", len(res), err)
+	}
+	r.Close()
+	if _, err = r.Read(make([]byte, 1)); err != io.ErrClosedPipe {
+		t.Errorf("should %v but %v", io.ErrClosedPipe, err)
+	}
+	if st := pool.Stats(); st.Hits == 0 {
+		t.Errorf("buffers are not reused: %+v", st)
+	}
+
+	// Close stops a prefetch that has not reached the end of src.
+	r = NewPrefetchReader(strings.NewReader(content), pool, 64)
+	b := make([]byte, 10)
+	if _, err = io.ReadFull(r, b); err != nil || string(b) != content[:10] {
+		t.Fatalf("unexpected result %s %v", b, err)
+	}
+	r.Close()
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestPrefetchReader_CloseGivesBuffersBack(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		pool := bytespool.NewCountingPool(fixed.NewBytePool(prefetchChunks+2, 64))
+		r := NewPrefetchReader(zeroReader{}, pool, 64)
+		if _, err := io.ReadFull(r, make([]byte, 100)); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+		if _, err := r.Read(make([]byte, 1)); err != io.ErrClosedPipe {
+			t.Fatalf("should %v but %v", io.ErrClosedPipe, err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for pool.OutstandingBytes() != 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d bytes are not given back to the pool", pool.OutstandingBytes())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
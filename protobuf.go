@@ -0,0 +1,164 @@
+package libio
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// longest field payload protobufStringReplace is able to rewrite, longer ones are
+// passed through unchanged. The whole field is the search token, so it bounds the
+// data StreamReplacingReader holds back.
+const protobufMaxPayloadLen = 1024
+
+type protobufStringReplace struct {
+	fieldNumber uint64
+	search      []byte
+	replace     []byte
+
+	offset int64 // source offset of the buffer handed to Index
+	next   int64 // source offset of the next field to parse
+	broken bool  // not a valid protobuf stream, stop parsing
+
+	// the rewritten field found at source offset fieldAt by the last Index, which
+	// is called again with it until the reader can decide on the match.
+	field   []byte
+	fieldAt int64
+}
+
+// NewProtobufStringReplacer returns a BytesReplacer replacing search with replace
+// inside the length-delimited fields numbered fieldNumber of a protobuf message
+// stream, re-encoding the field length when it changes. Only top level fields of
+// up to 1024 payload bytes are rewritten; parsing stops at the first malformed
+// field.
+//
+// The replacer follows the field layout of the stream through OffsetSetter and
+// MatchObserver, so it must be handed to StreamReplacingReader directly or within
+// composites of this package, and a new one is needed for each stream.
+func NewProtobufStringReplacer(fieldNumber int, search, replace string) BytesReplacer {
+	if fieldNumber <= 0 {
+		panic("libio.NewProtobufStringReplacer: invalid field number")
+	}
+	if len(search) == 0 {
+		panic("libio.NewProtobufStringReplacer: search token cannot be empty")
+	}
+	return &protobufStringReplace{
+		fieldNumber: uint64(fieldNumber),
+		search:      []byte(search),
+		replace:     []byte(replace),
+	}
+}
+
+func (p *protobufStringReplace) GetSizingHints() (int, int, float64) {
+	searchLen, replaceLen := len(p.search), len(p.replace)
+	keyLen := uvarintLen(p.fieldNumber<<3 | 2)
+	maxField := keyLen + uvarintLen(protobufMaxPayloadLen) + protobufMaxPayloadLen
+	if replaceLen <= searchLen {
+		// the payload and so its length varint can only shrink.
+		return maxField, maxField, -1
+	}
+	maxPayload := (protobufMaxPayloadLen*replaceLen + searchLen - 1) / searchLen
+	// a rewritten field has at least one occurrence, which grows by
+	// replaceLen-searchLen, and the length varint grows by the same extra bytes for
+	// the whole field.
+	varintGrowth := uvarintLen(uint64(maxPayload)) - 1
+	ratio := float64(searchLen) / float64(replaceLen+varintGrowth)
+	return maxField, keyLen + uvarintLen(uint64(maxPayload)) + maxPayload, ratio
+}
+
+func uvarintLen(v uint64) int {
+	n := 1
+	for ; v >= 0x80; v >>= 7 {
+		n++
+	}
+	return n
+}
+
+func (p *protobufStringReplace) SetOffset(offset int64) {
+	p.offset = offset
+}
+
+func (p *protobufStringReplace) Matched(offset int64, search, replace []byte) {
+	p.next = offset + int64(len(search))
+	p.field = nil
+}
+
+func (p *protobufStringReplace) clone() (BytesReplacer, bool) {
+	c := *p
+	c.field = append([]byte(nil), p.field...)
+	return &c, true
+}
+
+func (p *protobufStringReplace) Index(buf []byte) (int, []byte, []byte) {
+	if p.broken {
+		return -1, nil, nil
+	}
+	pos := int(p.next - p.offset)
+	if pos < 0 {
+		p.broken = true
+		return -1, nil, nil
+	}
+	for pos < len(buf) {
+		key, n := binary.Uvarint(buf[pos:])
+		if n == 0 {
+			return -1, nil, nil // incomplete
+		}
+		if n < 0 {
+			p.broken = true
+			return -1, nil, nil
+		}
+
+		total := n
+		switch key & 7 {
+		case 0: // varint
+			_, m := binary.Uvarint(buf[pos+n:])
+			if m == 0 {
+				return -1, nil, nil
+			}
+			if m < 0 {
+				p.broken = true
+				return -1, nil, nil
+			}
+			total += m
+		case 1: // fixed64
+			total += 8
+		case 5: // fixed32
+			total += 4
+		case 3, 4: // group start/end, fields inside are parsed as top level ones
+		case 2: // length-delimited
+			l, m := binary.Uvarint(buf[pos+n:])
+			if m == 0 {
+				return -1, nil, nil
+			}
+			if m < 0 || l > 1<<31 {
+				p.broken = true
+				return -1, nil, nil
+			}
+			total += m + int(l)
+			if key>>3 == p.fieldNumber && l <= protobufMaxPayloadLen {
+				if pos+total > len(buf) {
+					return -1, nil, nil // wait for the whole field
+				}
+				if p.field != nil && p.fieldAt == p.offset+int64(pos) {
+					return pos, buf[pos : pos+total], p.field
+				}
+				payload := buf[pos+n+m : pos+total]
+				if bytes.Contains(payload, p.search) {
+					payload = bytes.ReplaceAll(payload, p.search, p.replace)
+					field := make([]byte, n, n+binary.MaxVarintLen64+len(payload))
+					copy(field, buf[pos:pos+n])
+					var lenBuf [binary.MaxVarintLen64]byte
+					field = append(field, lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(payload)))]...)
+					p.field = append(field, payload...)
+					p.fieldAt = p.offset + int64(pos)
+					return pos, buf[pos : pos+total], p.field
+				}
+			}
+		default:
+			p.broken = true
+			return -1, nil, nil
+		}
+		p.next += int64(total)
+		pos += total
+	}
+	return -1, nil, nil
+}
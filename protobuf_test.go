@@ -0,0 +1,75 @@
+package libio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func appendProtobufString(b []byte, field int, s string) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	b = append(b, tmp[:binary.PutUvarint(tmp[:], uint64(field<<3|2))]...)
+	b = append(b, tmp[:binary.PutUvarint(tmp[:], uint64(len(s)))]...)
+	return append(b, s...)
+}
+
+func appendProtobufVarint(b []byte, field int, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	b = append(b, tmp[:binary.PutUvarint(tmp[:], uint64(field<<3))]...)
+	return append(b, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func TestProtobufStringReplacer(t *testing.T) {
+	long := strings.Repeat("x", 120) + "foo"
+	var src, expect []byte
+	for i := 0; i < 100; i++ {
+		src = appendProtobufString(src, 1, "hello foo")
+		src = appendProtobufVarint(src, 2, 150)
+		src = appendProtobufString(src, 1, long)
+		src = appendProtobufString(src, 3, "foo")
+
+		expect = appendProtobufString(expect, 1, "hello barbaz")
+		expect = appendProtobufVarint(expect, 2, 150)
+		expect = appendProtobufString(expect, 1, strings.Repeat("x", 120)+"barbaz")
+		expect = appendProtobufString(expect, 3, "foo")
+	}
+
+	for _, src := range []io.Reader{bytes.NewReader(src), iotest.HalfReader(bytes.NewReader(src))} {
+		r := (&StreamReplacingReader{}).ResetEx(src, NewProtobufStringReplacer(1, "foo", "barbaz"))
+		res, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(res, expect) {
+			t.Errorf("should %q but %q", expect, res)
+		}
+	}
+}
+
+type readCountingReader struct {
+	io.Reader
+	reads int
+}
+
+func (r *readCountingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.Reader.Read(p)
+}
+
+func TestProtobufStringReplacer_HoldsBackLittle(t *testing.T) {
+	var src []byte
+	for len(src) < 22000 {
+		src = appendProtobufString(src, 1, "hello foo")
+	}
+	counting := &readCountingReader{Reader: bytes.NewReader(src)}
+	r := (&StreamReplacingReader{}).ResetEx(counting, NewProtobufStringReplacer(1, "foo", "barbaz"))
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	if counting.reads > 100 {
+		t.Errorf("should read the source in few reads but %d", counting.reads)
+	}
+}
@@ -0,0 +1,85 @@
+package libio
+
+import (
+	"io"
+	"net/url"
+)
+
+type queryStringReplace struct {
+	key      string
+	oldValue string
+	replace  []byte
+	maxLen   int
+}
+
+// NewQueryStringReplacer returns a BytesReplacer that replaces the value of the
+// query string parameters named key whose value is oldValue with newValue. Names
+// and values are compared once percent-decoded, so "a%20b" and "a+b" both match
+// "a b"; newValue is written percent-encoded. Parameters are recognized after a
+// '?' or a '&', and their value ends at a '&', a '#', a quote or white space.
+func NewQueryStringReplacer(key, oldValue, newValue string) BytesReplacer {
+	if len(key) == 0 {
+		panic("libio.NewQueryStringReplacer: key cannot be empty")
+	}
+	return &queryStringReplace{
+		key:      key,
+		oldValue: oldValue,
+		replace:  []byte(url.QueryEscape(newValue)),
+		// every byte can be percent-encoded, plus the separators and the end of the value.
+		maxLen: 1 + 3*len(key) + 1 + 3*len(oldValue) + 1,
+	}
+}
+
+func (q *queryStringReplace) GetSizingHints() (int, int, float64) {
+	// matches go from the '?' or '&' to the end of the value, and are replaced by
+	// the same prefix followed by the new value.
+	minLen := 1 + len(q.key) + 1 + len(q.oldValue)
+	replaceLen := 1 + 3*len(q.key) + 1 + len(q.replace)
+	ratio := float64(-1)
+	if minLen < replaceLen {
+		ratio = float64(minLen) / float64(replaceLen)
+	}
+	return q.maxLen, replaceLen, ratio
+}
+
+func (q *queryStringReplace) Index(buf []byte) (int, []byte, []byte) {
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != '?' && buf[i] != '&' {
+			continue
+		}
+		eq := i + 1
+		for eq < len(buf) && eq-i <= 3*len(q.key) && buf[eq] != '=' && !isQueryValueEnd(buf[eq]) {
+			eq++
+		}
+		if eq == len(buf) || buf[eq] != '=' {
+			continue
+		}
+		if key, err := url.QueryUnescape(string(buf[i+1 : eq])); err != nil || key != q.key {
+			continue
+		}
+		start := eq + 1
+		end := start
+		for end < len(buf) && end-start <= 3*len(q.oldValue) && !isQueryValueEnd(buf[end]) {
+			end++
+		}
+		// a value reaching the end of buf is reported even though it may go on: the
+		// reader does not replace it before it is known to be complete.
+		if value, err := url.QueryUnescape(string(buf[start:end])); err == nil && value == q.oldValue {
+			replace := make([]byte, 0, start-i+len(q.replace))
+			return i, buf[i:end], append(append(replace, buf[i:start]...), q.replace...)
+		}
+	}
+	return -1, nil, nil
+}
+
+func (q *queryStringReplace) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, q)
+}
+
+func isQueryValueEnd(c byte) bool {
+	switch c {
+	case '&', '#', '"', '\'', ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestQueryStringReplacer(t *testing.T) {
+	src := "GET /a?token=s%20x&mytoken=s+x&token=s+x2 HTTP/1.1\nGET /b?x=1&token=s+x HTTP/1.1\n"
+	want := "GET /a?token=%2A%2A%2A&mytoken=s+x&token=s+x2 HTTP/1.1\nGET /b?x=1&token=%2A%2A%2A HTTP/1.1\n"
+	r := (&StreamReplacingReader{}).ResetEx(iotest.OneByteReader(strings.NewReader(src)), NewQueryStringReplacer("token", "s x", "***"))
+	res, _ := io.ReadAll(r)
+	if string(res) != want {
+		t.Errorf("should %q but %q", want, res)
+	}
+}
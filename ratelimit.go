@@ -0,0 +1,93 @@
+package libio
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrReplacementRateExceeded is returned by the readers of NewRateLimitedReplacer
+// when the source has more matches than allowed.
+var ErrReplacementRateExceeded = errors.New("libio: replacement rate exceeded")
+
+type rateLimitedReplacer struct {
+	inner      Replacer
+	maxPerByte float64
+}
+
+// NewRateLimitedReplacer returns a Replacer that replaces like inner but fails
+// with ErrReplacementRateExceeded once the replacements delivered exceed
+// maxPerByte per byte delivered, to guard against inputs crafted to trigger as
+// many replacements as possible. A replacement is charged once Read has returned
+// all of it, and the rate is measured from the start of the stream after every
+// Read. inner must be built on StreamReplacingReader, as the replacers of this
+// package are.
+func NewRateLimitedReplacer(inner Replacer, maxPerByte float64) Replacer {
+	if _, ok := bytesReplacerOf(inner); !ok {
+		panic("libio.NewRateLimitedReplacer: inner replacer is not based on StreamReplacingReader")
+	}
+	if maxPerByte < 0 {
+		panic("libio.NewRateLimitedReplacer: rate cannot be negative")
+	}
+	return &rateLimitedReplacer{inner: inner, maxPerByte: maxPerByte}
+}
+
+func (r *rateLimitedReplacer) Replace(src io.Reader) io.Reader {
+	br, _ := bytesReplacerOf(r.inner)
+	ri := &rateIndex{BytesReplacer: br}
+	return &rateLimitedReader{r: (&StreamReplacingReader{}).ResetEx(src, ri), ri: ri, maxPerByte: r.maxPerByte}
+}
+
+// rateIndex records where each match of the BytesReplacer it wraps ends in the
+// output.
+type rateIndex struct {
+	BytesReplacer
+	ends  []int64 // output offsets of the ends of the matches not delivered yet
+	delta int64   // output offset - source offset
+}
+
+func (ri *rateIndex) SetOffset(offset int64) {
+	if os, ok := ri.BytesReplacer.(OffsetSetter); ok {
+		os.SetOffset(offset)
+	}
+}
+
+func (ri *rateIndex) Matched(offset int64, search, replace []byte) {
+	if mo, ok := ri.BytesReplacer.(MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+	ri.delta += int64(len(replace) - len(search))
+	ri.ends = append(ri.ends, offset+int64(len(search))+ri.delta)
+}
+
+func (ri *rateIndex) stopAtMatch() bool {
+	s, ok := ri.BytesReplacer.(stopper)
+	return ok && s.stopAtMatch()
+}
+
+type rateLimitedReader struct {
+	r          *StreamReplacingReader
+	ri         *rateIndex
+	maxPerByte float64
+	delivered  int64
+	charged    int64
+	err        error
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.r.Read(p)
+	r.delivered += int64(n)
+	i := 0
+	for i < len(r.ri.ends) && r.ri.ends[i] <= r.delivered {
+		i++
+	}
+	r.charged += int64(i)
+	r.ri.ends = append(r.ri.ends[:0], r.ri.ends[i:]...)
+	if float64(r.charged) > r.maxPerByte*float64(r.delivered) {
+		r.err = ErrReplacementRateExceeded
+		return n, r.err
+	}
+	return n, err
+}
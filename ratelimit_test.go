@@ -0,0 +1,45 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRateLimitedReplacer(t *testing.T) {
+	r := NewRateLimitedReplacer(NewReplacer("a", "xx"), 0.1)
+
+	src := strings.Repeat("a"+strings.Repeat(".", 19), 100)
+	res, err := io.ReadAll(r.Replace(strings.NewReader(src)))
+	if err != nil || len(res) != 100*21 {
+		t.Errorf("should replace a source below the rate, got %d bytes %v", len(res), err)
+	}
+
+	_, err = io.ReadAll(r.Replace(strings.NewReader(strings.Repeat("a", 10000))))
+	if err != ErrReplacementRateExceeded {
+		t.Errorf("should fail on a source above the rate but %v", err)
+	}
+}
+
+func TestRateLimitedReplacer_ChargesDeliveredBytes(t *testing.T) {
+	// the replacements made ahead of Read are only charged once delivered.
+	r := NewRateLimitedReplacer(NewReplacer("a", "xx"), 0.5)
+	rr := r.Replace(strings.NewReader(strings.Repeat("a", 1000) + strings.Repeat(".", 3000)))
+	p := make([]byte, 3)
+	for {
+		_, err := rr.Read(p)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("should stay within the rate but %v", err)
+		}
+	}
+
+	// the rate is per delivered byte, not per source byte.
+	r = NewRateLimitedReplacer(NewReplacer("ab", "x"), 0.6)
+	_, err := io.ReadAll(r.Replace(strings.NewReader(strings.Repeat("ab", 1000))))
+	if err != ErrReplacementRateExceeded {
+		t.Errorf("should fail on a source above the rate but %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package libio
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// regexReplace is a BytesReplacer replacing every match of re with a literal
+// replacement. The pattern must have a bounded match length so that
+// StreamReplacingReader can size its lookahead; note that a match is only found
+// once it is entirely within the buffer, so patterns whose matches can be
+// extended by later input (e.g. "a{1,3}") may match a shorter text at a buffer
+// boundary.
+type regexReplace struct {
+	re      *regexp.Regexp
+	replace []byte
+	minLen  int
+	maxLen  int
+}
+
+func newRegexReplace(pattern, replace string) (*regexReplace, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	st, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	minLen, maxLen := regexLenBounds(st.Simplify())
+	if maxLen < 0 {
+		return nil, fmt.Errorf("libio: regex %q has no upper bound on match length", pattern)
+	}
+	if minLen == 0 {
+		return nil, fmt.Errorf("libio: regex %q can match the empty string", pattern)
+	}
+	return &regexReplace{re: re, replace: []byte(replace), minLen: minLen, maxLen: maxLen}, nil
+}
+
+func (r *regexReplace) GetSizingHints() (int, int, float64) {
+	replaceLen := len(r.replace)
+	ratio := float64(-1)
+	if r.minLen < replaceLen {
+		ratio = float64(r.minLen) / float64(replaceLen)
+	}
+	return r.maxLen, replaceLen, ratio
+}
+
+func (r *regexReplace) Index(buf []byte) (int, []byte, []byte) {
+	loc := r.re.FindIndex(buf)
+	if loc == nil {
+		return -1, nil, nil
+	}
+	return loc[0], buf[loc[0]:loc[1]], r.replace
+}
+
+// regexLenBounds returns the min and max length in bytes of the texts matched
+// by re. max is -1 if unbounded.
+func regexLenBounds(re *syntax.Regexp) (int, int) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		minLen, maxLen := 0, 0
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				minLen++
+				maxLen += utf8.UTFMax
+			} else {
+				minLen += utf8.RuneLen(r)
+				maxLen += utf8.RuneLen(r)
+			}
+		}
+		return minLen, maxLen
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return 0, 0
+		}
+		minLen, maxLen := utf8.UTFMax, 0
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			minLen = min(minLen, runeLen(re.Rune[i]))
+			maxLen = max(maxLen, runeLen(re.Rune[i+1]))
+		}
+		return minLen, maxLen
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		return 1, utf8.UTFMax
+	case syntax.OpCapture:
+		return regexLenBounds(re.Sub[0])
+	case syntax.OpStar:
+		return 0, -1
+	case syntax.OpPlus:
+		minLen, _ := regexLenBounds(re.Sub[0])
+		return minLen, -1
+	case syntax.OpQuest:
+		_, maxLen := regexLenBounds(re.Sub[0])
+		return 0, maxLen
+	case syntax.OpRepeat:
+		minLen, maxLen := regexLenBounds(re.Sub[0])
+		if re.Max < 0 || maxLen < 0 {
+			return minLen * re.Min, -1
+		}
+		return minLen * re.Min, maxLen * re.Max
+	case syntax.OpConcat:
+		minLen, maxLen := 0, 0
+		for _, sub := range re.Sub {
+			subMin, subMax := regexLenBounds(sub)
+			minLen += subMin
+			if maxLen >= 0 {
+				maxLen += subMax
+			}
+			if subMax < 0 {
+				maxLen = -1
+			}
+		}
+		return minLen, maxLen
+	case syntax.OpAlternate:
+		minLen, maxLen := -1, 0
+		for _, sub := range re.Sub {
+			subMin, subMax := regexLenBounds(sub)
+			if minLen < 0 || subMin < minLen {
+				minLen = subMin
+			}
+			if maxLen >= 0 && (subMax < 0 || subMax > maxLen) {
+				maxLen = subMax
+			}
+		}
+		return max(minLen, 0), maxLen
+	}
+	// empty width assertions and no-match
+	return 0, 0
+}
+
+// runeLen is utf8.RuneLen counting invalid runes as the single byte they match.
+func runeLen(r rune) int {
+	if n := utf8.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,27 @@
+package libio
+
+import "io"
+
+var regexEscaper = NewReplacer(
+	`\`, `\\`,
+	`.`, `\.`,
+	`*`, `\*`,
+	`+`, `\+`,
+	`?`, `\?`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`(`, `\(`,
+	`)`, `\)`,
+	`^`, `\^`,
+	`$`, `\$`,
+	`|`, `\|`,
+)
+
+// NewRegexEscapeReader returns a reader of src with every regular expression
+// metacharacter escaped with a backslash, like regexp.QuoteMeta does, so that the
+// output matches src literally when used as a pattern.
+func NewRegexEscapeReader(src io.Reader) io.Reader {
+	return regexEscaper.Replace(src)
+}
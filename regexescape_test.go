@@ -0,0 +1,17 @@
+package libio
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRegexEscapeReader(t *testing.T) {
+	src := `a.b*c+d?e[f]g{h}i(j)k^l$m|n\o`
+	res, _ := io.ReadAll(NewRegexEscapeReader(iotest.OneByteReader(strings.NewReader(src))))
+	if want := regexp.QuoteMeta(src); string(res) != want {
+		t.Errorf("should %s but %s", want, res)
+	}
+}
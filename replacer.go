@@ -2,13 +2,59 @@ package libio
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Replacer interface {
 	Replace(reader io.Reader) io.Reader
 }
 
+// streamBytesReplacer is implemented by Replacers that keep per stream state on top
+// of a BytesReplacer; newBytesReplacer returns the BytesReplacer for a new stream.
+type streamBytesReplacer interface {
+	newBytesReplacer() BytesReplacer
+}
+
+// cloner is implemented by BytesReplacers keeping per stream state; clone returns
+// a copy with its own state for StreamReplacingReader.Clone.
+type cloner interface {
+	clone() (BytesReplacer, bool)
+}
+
+// cloneBytesReplacer returns br, or a copy of it if it keeps per stream state,
+// false if it does but cannot be copied.
+func cloneBytesReplacer(br BytesReplacer) (BytesReplacer, bool) {
+	switch b := br.(type) {
+	case cloner:
+		return b.clone()
+	case MatchObserver, stopper:
+		return br, false
+	}
+	return br, true
+}
+
+// bytesReplacerOf returns the BytesReplacer to use for a new stream of r, false if
+// r is not built on StreamReplacingReader.
+func bytesReplacerOf(r Replacer) (BytesReplacer, bool) {
+	switch rr := r.(type) {
+	case streamBytesReplacer:
+		return rr.newBytesReplacer(), true
+	case BytesReplacer:
+		return rr, true
+	case *AtomicReplacer:
+		return bytesReplacerOf(rr.Load())
+	}
+	return nil, false
+}
+
 // BytesReplacer allows customization on how StreamReplacingReader does sizing estimate during
 // initialization/reset and does search and replacement during the execution.
 type BytesReplacer interface {
@@ -16,7 +62,7 @@ type BytesReplacer interface {
 	// Return values:
 	// - 1st: max search token len
 	// - 2nd: max replace token len
-	// - 3rd: max (search_len / replace_len) ratio that is less than 1,
+	// - 3rd: min (search_len / replace_len) ratio among the ones that are less than 1,
 	//        if none of the search/replace ratio is less than 1, then return a negative number.
 	// will only be called once during StreamReplacingReader initialization/reset.
 	GetSizingHints() (int, int, float64)
@@ -28,7 +74,33 @@ type BytesReplacer interface {
 	Index(buf []byte) (int, []byte, []byte)
 }
 
-const defaultBufSize = int(4096)
+// OffsetSetter is an optional interface for BytesReplacer implementations that need
+// to know where the searched buffer lies in the source. StreamReplacingReader calls
+// SetOffset with the source offset of buf right before each Index(buf) call.
+type OffsetSetter interface {
+	SetOffset(offset int64)
+}
+
+// MatchObserver is an optional interface for BytesReplacer implementations that keep
+// per stream state. Index may report the same match several times while
+// StreamReplacingReader waits for enough data to decide on it, so state must not be
+// updated there: Matched is called exactly once for every match that is replaced,
+// with the source offset of the match, right after the Index call that returned it.
+type MatchObserver interface {
+	Matched(offset int64, search, replace []byte)
+}
+
+var defaultBufSize = int(4096)
+
+// SetDefaultBufSize changes the minimum buffer size of StreamReplacingReaders
+// initialized afterwards (4096 by default). It is not safe to call concurrently
+// with the creation of readers, so it should be called once at program startup.
+func SetDefaultBufSize(n int) {
+	if n <= 0 {
+		panic("libio.SetDefaultBufSize: size must be positive")
+	}
+	defaultBufSize = n
+}
 
 func max(a, b int) int {
 	if a > b {
@@ -37,17 +109,103 @@ func max(a, b int) int {
 	return b
 }
 
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ReplacerTelemetry is a snapshot of the counters of a StreamReplacingReader.
+type ReplacerTelemetry struct {
+	BytesIn           int64 // bytes read from the source
+	BytesOut          int64 // bytes delivered to the caller
+	ReplacementsCount int64 // number of replacements made
+}
+
 // StreamReplacingReader allows transparent replacement of a given token during read operation.
+// Like most readers it is not safe for concurrent use, except for Telemetry; use
+// NewSyncStreamReplacingReader for a reader shared by several goroutines.
+//
+// The reader works on a single buffer split in three parts:
+//   - buf[0:buf0] is processed output, ready to be delivered by Read;
+//   - buf[buf0:buf1] is source data read in but not processed yet, either because
+//     it has not been searched yet or because it might be the beginning of a search
+//     token whose end has not been read yet;
+//   - buf[buf1:max] is free for the next read from the source.
+//
+// 0 <= buf0 <= buf1 <= len(buf) always holds; buf1 only goes past max when
+// replacements make the processed data grow. BytesReplacer.Index is only ever
+// given buf[buf0:buf1], and a match is only replaced once maxSearchTokenLen bytes
+// (or the lookahead set by WithOverlapHint) from its start are available, or the
+// source is exhausted, so that a longer token starting at the same position or
+// earlier cannot be missed. When replace tokens are longer than their search
+// tokens, len(buf) leaves room for the longest one past max, and process stops
+// before a replacement that does not fit until Read has made room.
 type StreamReplacingReader struct {
+	// updated atomically, kept first for 64-bit alignment.
+	bytesIn           int64
+	bytesOut          int64
+	replacements      int64
 	replacer          BytesReplacer
+	offsetSetter      OffsetSetter
+	observer          MatchObserver
+	stopper           stopper
 	maxSearchTokenLen int
+	lookahead         int     // bytes needed after a position to decide on a match there
+	expansion         float64 // max output bytes per source byte
 	r                 io.Reader
 	err               error
 	buf               []byte
 	// buf[0:buf0]: bytes already processed; buf[buf0:buf1] bytes read in but not yet processed.
+	// Read delivers buf[0:buf0] and moves buf[buf0:buf1] back to the start of buf.
 	buf0, buf1 int
 	// because we need to replace 'search' with 'replace', this marks the max bytes we can read into buf
 	max int
+	// process ran out of room for a replacement, buf[buf0:buf1] is left to process
+	// before reading more.
+	stalled bool
+	// options
+	maxLatency  time.Duration
+	overlapHint int
+	crlf        bool
+	cr          bool // a '\r' ending the last read is held back, see normalizeCRLF
+}
+
+// ReaderOption configures a StreamReplacingReader created by NewStreamReplacingReader.
+type ReaderOption func(*StreamReplacingReader)
+
+// NewStreamReplacingReader returns a reader of src with the tokens of replacer
+// replaced, configured by opts.
+func NewStreamReplacingReader(src io.Reader, replacer BytesReplacer, opts ...ReaderOption) *StreamReplacingReader {
+	r := &StreamReplacingReader{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r.ResetEx(src, replacer)
+}
+
+// WithOverlapHint tells the reader that n bytes from a position, n being at most
+// the longest search token, are enough to tell whether a token starts there, e.g.
+// because the tokens end with a delimiter that cannot appear inside them. Less data
+// is then held back between reads. A hint that is too small makes the reader miss
+// tokens spanning reads.
+func WithOverlapHint(n int) ReaderOption {
+	return func(r *StreamReplacingReader) {
+		r.overlapHint = n
+	}
+}
+
+// setSource switches to the source r1, stopping the read ahead goroutine of the
+// previous one if any.
+func (r *StreamReplacingReader) setSource(r1 io.Reader) {
+	if ls, ok := r.r.(*latencySource); ok {
+		ls.close()
+	}
+	if r.maxLatency > 0 {
+		r1 = newLatencySource(r1)
+	}
+	r.r = r1
 }
 
 func (r *StreamReplacingReader) ResetEx(r1 io.Reader, replacer BytesReplacer) *StreamReplacingReader {
@@ -55,71 +213,398 @@ func (r *StreamReplacingReader) ResetEx(r1 io.Reader, replacer BytesReplacer) *S
 		panic("io.Reader cannot be nil")
 	}
 	r.replacer = replacer
+	r.offsetSetter, _ = replacer.(OffsetSetter)
+	r.observer, _ = replacer.(MatchObserver)
+	r.stopper, _ = replacer.(stopper)
 	maxSearchTokenLen, maxReplaceTokenLen, maxSearchOverReplaceLenRatio := r.replacer.GetSizingHints()
 	if maxSearchTokenLen == 0 {
 		panic("search token cannot be nil/empty")
 	}
 	r.maxSearchTokenLen = maxSearchTokenLen
-	r.r = r1
+	r.lookahead = maxSearchTokenLen
+	if r.overlapHint > 0 && r.overlapHint < maxSearchTokenLen {
+		r.lookahead = r.overlapHint
+	}
+	r.expansion = 1
+	if maxSearchOverReplaceLenRatio > 0 {
+		r.expansion = 1 / maxSearchOverReplaceLenRatio
+	}
+	r.setSource(r1)
 	r.err = nil
-	bufSize := max(defaultBufSize, max(maxSearchTokenLen, maxReplaceTokenLen))
+	r.cr = false
+	r.stalled = false
+	// buf[:max] must be able to hold the longest search token.
+	window := max(defaultBufSize, maxSearchTokenLen)
+	bufSize := max(window, maxReplaceTokenLen)
+	if maxSearchOverReplaceLenRatio > 0 {
+		// a replacement growing the data may start anywhere in buf[:max], the ones
+		// that do not fit wait for Read to make room.
+		bufSize = window + maxReplaceTokenLen
+	}
 	if r.buf == nil || len(r.buf) < bufSize {
 		r.buf = make([]byte, bufSize)
 	}
 	r.buf0 = 0
 	r.buf1 = 0
+	atomic.StoreInt64(&r.bytesIn, 0)
+	atomic.StoreInt64(&r.bytesOut, 0)
+	atomic.StoreInt64(&r.replacements, 0)
 	r.max = len(r.buf)
 	if maxSearchOverReplaceLenRatio > 0 {
-		// If len(search) < len(replace), then we have to assume the worst case:
-		// what's the max bound value such that if we have consecutive 'search' filling up
-		// the buf up to buf[:max], and all of them are placed with 'replace', and the final
-		// result won't end up exceed the len(buf)?
-		r.max = int(maxSearchOverReplaceLenRatio * float64(len(r.buf)))
+		r.max = len(r.buf) - maxReplaceTokenLen
 	}
 	return r
 }
 
+// Reset switches the reader to a new source, keeping the replacer, sizing and
+// buffer set up by ResetEx. This allows reusing a reader across streams without
+// reallocating.
+func (r *StreamReplacingReader) Reset(r1 io.Reader) *StreamReplacingReader {
+	if r1 == nil {
+		panic("io.Reader cannot be nil")
+	}
+	if r.replacer == nil {
+		panic("StreamReplacingReader must be initialized by ResetEx before Reset")
+	}
+	r.setSource(r1)
+	r.err = nil
+	r.cr = false
+	r.stalled = false
+	r.buf0 = 0
+	r.buf1 = 0
+	atomic.StoreInt64(&r.bytesIn, 0)
+	atomic.StoreInt64(&r.bytesOut, 0)
+	atomic.StoreInt64(&r.replacements, 0)
+	return r
+}
+
 func (r *StreamReplacingReader) Read(p []byte) (int, error) {
 	n := 0
 	for {
 		if r.buf0 > 0 {
 			n = copy(p, r.buf[0:r.buf0])
+			atomic.AddInt64(&r.bytesOut, int64(n))
 			r.buf0 -= n
 			r.buf1 -= n
-			if r.buf1 == 0 && r.err != nil {
+			if r.buf1 == 0 && r.err != nil && !r.stalled {
 				return n, r.err
 			}
 			copy(r.buf, r.buf[n:r.buf1+n])
 			return n, nil
-		} else if r.err != nil {
+		} else if r.err != nil && !r.stalled {
 			return 0, r.err
 		}
 
-		n, r.err = r.r.Read(r.buf[r.buf1:r.max])
-		if n > 0 {
-			r.buf1 += n
-			for {
-				index, search, replace := r.replacer.Index(r.buf[r.buf0:r.buf1])
-				if index < 0 {
-					r.buf0 = max(r.buf0, r.buf1-r.maxSearchTokenLen+1)
-					break
-				}
-				searchTokenLen := len(search)
-				if searchTokenLen == 0 {
-					panic("search token cannot be nil/empty")
-				}
-				replaceTokenLen := len(replace)
-				lenDelta := replaceTokenLen - searchTokenLen
-				index += r.buf0
-				copy(r.buf[index+replaceTokenLen:r.buf1+lenDelta], r.buf[index+searchTokenLen:r.buf1])
-				copy(r.buf[index:index+replaceTokenLen], replace)
-				r.buf0 = index + replaceTokenLen
-				r.buf1 += lenDelta
+		if !r.fill() {
+			return 0, io.EOF
+		}
+	}
+}
+
+// Discard skips the next n bytes of the replaced output without copying them. It
+// returns the number of bytes discarded and, if fewer than n, the error that
+// stopped it.
+func (r *StreamReplacingReader) Discard(n int) (int, error) {
+	if n < 0 {
+		return 0, errors.New("StreamReplacingReader: negative count")
+	}
+	discarded := 0
+	for discarded < n {
+		if r.buf0 > 0 {
+			k := min(n-discarded, r.buf0)
+			atomic.AddInt64(&r.bytesOut, int64(k))
+			r.buf0 -= k
+			r.buf1 -= k
+			copy(r.buf, r.buf[k:r.buf1+k])
+			discarded += k
+			continue
+		} else if r.err != nil && !r.stalled {
+			return discarded, r.err
+		}
+
+		if !r.fill() {
+			return discarded, io.EOF
+		}
+	}
+	return discarded, nil
+}
+
+// fill reads once from the source into buf and processes what was read. It returns
+// false if a reader created by NewStreamReplacingReadWriter has no written data left.
+func (r *StreamReplacingReader) fill() bool {
+	if r.stalled {
+		r.process()
+		r.flushAtError()
+		return true
+	}
+	var n int
+	var err error
+	off := 0 // 1 if the '\r' held back by normalizeCRLF is put back in front of the read
+	if r.cr {
+		r.buf[r.buf1] = '\r'
+		r.cr = false
+		off = 1
+	}
+	if ls, ok := r.r.(*latencySource); ok && r.buf1+off > 0 {
+		// buf[:buf1] is held back, give up waiting for the rest of the token after
+		// maxLatency.
+		n, err = ls.read(r.buf[r.buf1+off:r.max], r.maxLatency)
+	} else {
+		n, err = r.r.Read(r.buf[r.buf1+off : r.max])
+	}
+	if err == errNoData {
+		// everything written so far is processed, wait for the next Write.
+		r.cr = off == 1
+		return false
+	}
+	if err == errFlush {
+		r.buf1 += off
+		r.buf0 = r.buf1
+		return true
+	}
+	r.err = err
+	if n > 0 {
+		atomic.AddInt64(&r.bytesIn, int64(n))
+	}
+	end := r.buf1 + off + n
+	if r.crlf {
+		start := r.buf1
+		if start > r.buf0 && r.buf[start-1] == '\r' {
+			// a '\r' not processed yet may pair with a '\n' just read.
+			start--
+		}
+		r.buf1 = start + r.normalizeCRLF(r.buf[start:end], err != nil)
+		if r.cr && r.buf1+1 >= r.max {
+			// the buffer is too full to read after the '\r' held back, which is
+			// still in buf[buf1]: keep it there, the next read pairs it with a '\n'.
+			r.cr = false
+			r.buf1++
+		}
+	} else {
+		r.buf1 = end
+	}
+	if off+n > 0 || r.err != nil {
+		r.process()
+	}
+	r.flushAtError()
+	return true
+}
+
+// flushAtError releases the bytes held back once the source is done, unless
+// process has some left to replace.
+func (r *StreamReplacingReader) flushAtError() {
+	if r.err != nil && !r.stalled {
+		r.buf0 = r.buf1
+	}
+}
+
+// process searches and replaces tokens in buf[buf0:buf1], moving buf0 past the
+// bytes that cannot be part of a match anymore.
+func (r *StreamReplacingReader) process() {
+	r.stalled = false
+	for r.buf0 < r.buf1 {
+		if r.offsetSetter != nil {
+			r.offsetSetter.SetOffset(atomic.LoadInt64(&r.bytesIn) - int64(r.buf1-r.buf0))
+		}
+		index, search, replace := r.replacer.Index(r.buf[r.buf0:r.buf1])
+		if index < 0 || (r.err == nil && r.buf0+index+r.lookahead > r.buf1) {
+			// no match, or a match that a token starting at or before it might still
+			// win once more data is read: wait until it can be decided.
+			r.buf0 = max(r.buf0, r.buf1-r.lookahead+1)
+			return
+		}
+		index += r.buf0
+		searchTokenLen := len(search)
+		if searchTokenLen == 0 {
+			panic("search token cannot be nil/empty")
+		}
+		replaceTokenLen := len(replace)
+		lenDelta := replaceTokenLen - searchTokenLen
+		if r.buf1+lenDelta > len(r.buf) {
+			// buf[index:buf1] is at most max bytes, so once Read has delivered
+			// buf[:buf0] the replacement fits.
+			if r.buf0 == 0 {
+				panic("replace token longer than the sizing hints")
 			}
+			r.stalled = true
+			return
+		}
+		if r.observer != nil {
+			r.observer.Matched(atomic.LoadInt64(&r.bytesIn)-int64(r.buf1-index), search, replace)
 		}
-		if r.err != nil {
-			r.buf0 = r.buf1
+		if r.stopper != nil && r.stopper.stopAtMatch() {
+			// end the stream right before the match, dropping it and what follows.
+			r.buf0, r.buf1 = index, index
+			r.err = io.EOF
+			return
 		}
+		if lenDelta != 0 {
+			// equal length tokens are replaced in place, no need to shift the tail.
+			copy(r.buf[index+replaceTokenLen:r.buf1+lenDelta], r.buf[index+searchTokenLen:r.buf1])
+		}
+		copy(r.buf[index:index+replaceTokenLen], replace)
+		r.buf0 = index + replaceTokenLen
+		r.buf1 += lenDelta
+		atomic.AddInt64(&r.replacements, 1)
+	}
+}
+
+// errNoData is returned by writeSource when all written bytes have been read but
+// the writer is not closed yet.
+var errNoData = errors.New("no data written yet")
+
+// writeSource is the source of a reader created by NewStreamReplacingReadWriter.
+type writeSource struct {
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (w *writeSource) Read(p []byte) (int, error) {
+	if w.buf.Len() == 0 {
+		if w.closed {
+			return 0, io.EOF
+		}
+		return 0, errNoData
+	}
+	return w.buf.Read(p)
+}
+
+// NewStreamReplacingReadWriter returns a StreamReplacingReader that is fed by its
+// Write method instead of a source reader. Like bytes.Buffer, Read returns io.EOF
+// once everything written so far has been consumed, and can be called again after
+// the next Write. Bytes that might be the beginning of a search token are held
+// back until more data is written or Close is called.
+func NewStreamReplacingReadWriter(replacer BytesReplacer) *StreamReplacingReader {
+	return (&StreamReplacingReader{}).ResetEx(&writeSource{}, replacer)
+}
+
+// Write feeds p to a reader created by NewStreamReplacingReadWriter.
+func (r *StreamReplacingReader) Write(p []byte) (int, error) {
+	ws, ok := r.r.(*writeSource)
+	if !ok {
+		return 0, errors.New("StreamReplacingReader is not created by NewStreamReplacingReadWriter")
+	}
+	if ws.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return ws.buf.Write(p)
+}
+
+// Close marks the end of the written data of a reader created by
+// NewStreamReplacingReadWriter, releasing the bytes held back. For a reader created
+// with WithMaxLatency it stops reading ahead the source.
+func (r *StreamReplacingReader) Close() error {
+	switch src := r.r.(type) {
+	case *writeSource:
+		src.closed = true
+	case *latencySource:
+		src.close()
+	}
+	return nil
+}
+
+// Clone returns a reader with the same replacer, options, counters and pending
+// data but its own buffer and position, so both can be read independently, e.g.
+// to fan out a stream. The rest of the source is read independently through
+// io.ReaderAt if the source is also an io.Seeker, like bytes.Reader and
+// os.File; a reader created by NewStreamReplacingReadWriter gets a copy of the
+// data written so far and its own Write. Replacers keeping per stream state, like
+// the ones of NewFieldReplacer or NewNthOccurrenceReplacer, are copied with it.
+// Clone panics if the source or the replacer cannot be cloned, which includes
+// readers created with WithMaxLatency.
+func (r *StreamReplacingReader) Clone() *StreamReplacingReader {
+	src := cloneSource(r.r)
+	replacer, ok := cloneBytesReplacer(r.replacer)
+	if !ok {
+		panic("libio.StreamReplacingReader.Clone: the replacer keeps per stream state and cannot be cloned")
+	}
+	c := &StreamReplacingReader{
+		bytesIn:           atomic.LoadInt64(&r.bytesIn),
+		bytesOut:          atomic.LoadInt64(&r.bytesOut),
+		replacements:      atomic.LoadInt64(&r.replacements),
+		replacer:          replacer,
+		maxSearchTokenLen: r.maxSearchTokenLen,
+		lookahead:         r.lookahead,
+		expansion:         r.expansion,
+		r:                 src,
+		err:               r.err,
+		buf:               make([]byte, len(r.buf)),
+		buf0:              r.buf0,
+		buf1:              r.buf1,
+		max:               r.max,
+		stalled:           r.stalled,
+		maxLatency:        r.maxLatency,
+		overlapHint:       r.overlapHint,
+		crlf:              r.crlf,
+		cr:                r.cr,
+	}
+	c.offsetSetter, _ = replacer.(OffsetSetter)
+	c.observer, _ = replacer.(MatchObserver)
+	c.stopper, _ = replacer.(stopper)
+	copy(c.buf, r.buf[:r.buf1])
+	return c
+}
+
+// cloneSource returns a reader of the rest of src that does not move src.
+func cloneSource(src io.Reader) io.Reader {
+	switch s := src.(type) {
+	case *writeSource:
+		c := &writeSource{closed: s.closed}
+		c.buf.Write(s.buf.Bytes())
+		return c
+	case *latencySource:
+		panic("libio.StreamReplacingReader.Clone: the source is read ahead")
+	}
+	ra, ok1 := src.(io.ReaderAt)
+	s, ok2 := src.(io.Seeker)
+	if !ok1 || !ok2 {
+		panic("libio.StreamReplacingReader.Clone: the source cannot be read independently")
+	}
+	cur, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		panic("libio.StreamReplacingReader.Clone: " + err.Error())
+	}
+	end, err := s.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = s.Seek(cur, io.SeekStart)
+	}
+	if err != nil {
+		panic("libio.StreamReplacingReader.Clone: " + err.Error())
+	}
+	return io.NewSectionReader(ra, cur, end-cur)
+}
+
+// Len returns an upper bound of the number of bytes left to read, assuming every
+// byte left in the source is part of the replacement growing the data the most.
+// It returns -1 if the source is not an io.Seeker, as the bytes left are unknown;
+// the source is seeked to its end and back.
+func (r *StreamReplacingReader) Len() int64 {
+	s, ok := r.r.(io.Seeker)
+	if !ok {
+		return -1
+	}
+	cur, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	end, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1
+	}
+	if _, err := s.Seek(cur, io.SeekStart); err != nil {
+		return -1
+	}
+	pending := float64(end-cur) + float64(r.buf1-r.buf0)
+	return int64(r.buf0) + int64(math.Ceil(pending*r.expansion))
+}
+
+// Telemetry returns the counters of the current stream. It is safe to call
+// concurrently with Read.
+func (r *StreamReplacingReader) Telemetry() ReplacerTelemetry {
+	return ReplacerTelemetry{
+		BytesIn:           atomic.LoadInt64(&r.bytesIn),
+		BytesOut:          atomic.LoadInt64(&r.bytesOut),
+		ReplacementsCount: atomic.LoadInt64(&r.replacements),
 	}
 }
 
@@ -139,6 +624,9 @@ func (b *byteReplace) GetSizingHints() (int, int, float64) {
 }
 
 func (b *byteReplace) Index(buf []byte) (int, []byte, []byte) {
+	if len(b.search) == 1 {
+		return indexByteSIMD(buf, b.search[0]), b.search, b.replace
+	}
 	return bytes.Index(buf, b.search), b.search, b.replace
 }
 
@@ -148,42 +636,185 @@ type replacer struct {
 
 	maxSearchLen  int
 	maxReplaceLen int
-	maxRatio      float64
+	minRatio      float64
 }
 
 func NewReplacer(oldnews ...string) Replacer {
-	res := &replacer{
-		replaces: make([]BytesReplacer, 0),
-		maxRatio: -1,
-	}
+	return NewReplacerWithOptions(oldnews)
+}
 
+// NewReplacerCompiled is like NewReplacer but fails instead of accepting an odd
+// argument count or search tokens one of which is a prefix of another, for which
+// the token replaced depends on the order of the arguments. The error names the
+// first conflicting pair found.
+func NewReplacerCompiled(oldnews ...string) (Replacer, error) {
 	if len(oldnews)%2 == 1 {
-		panic("stream.NewReplacer: odd argument count")
+		return nil, errors.New("libio.NewReplacerCompiled: odd argument count")
 	}
-
+	searches := make([]string, 0, len(oldnews)/2)
 	for i := 0; i < len(oldnews); i += 2 {
-		if len(oldnews[i]) == 0 { // search can not be empty
-			continue
+		if len(oldnews[i]) > 0 {
+			searches = append(searches, oldnews[i])
 		}
-		er := &byteReplace{search: []byte(oldnews[i]), replace: []byte(oldnews[i+1])}
-		res.replaces = append(res.replaces, er)
-		searchLen, replaceLen, ratio := er.GetSizingHints()
-		if searchLen > res.maxSearchLen {
-			res.maxSearchLen = searchLen
+	}
+	// once sorted, a token that is a prefix of others is followed by one of them.
+	sort.Strings(searches)
+	for i := 1; i < len(searches); i++ {
+		if strings.HasPrefix(searches[i], searches[i-1]) {
+			return nil, fmt.Errorf("libio.NewReplacerCompiled: search token %q is a prefix of %q", searches[i-1], searches[i])
 		}
-		if replaceLen > res.maxReplaceLen {
-			res.maxReplaceLen = replaceLen
+	}
+	return NewReplacerWithOptions(oldnews), nil
+}
+
+// NewWrappingReplacer returns a Replacer that wraps every occurrence of search
+// between prefix and suffix, e.g. "TODO" becomes "[TODO]" with "[" and "]".
+func NewWrappingReplacer(search, prefix, suffix string) Replacer {
+	return NewReplacer(search, prefix+search+suffix)
+}
+
+// NewCompositeReplacer returns a Replacer that merges the sizing hints of rs and
+// replaces the leftmost match among all of them; on a tie the replacer given first
+// wins. The returned Replacer is also a BytesReplacer, so composites can be nested.
+// The optional interfaces StreamReplacingReader relies on are forwarded to rs, and
+// the per stream state of rs is renewed for each stream of Replace.
+func NewCompositeReplacer(rs ...BytesReplacer) Replacer {
+	res := &replacer{
+		replaces: make([]BytesReplacer, 0, len(rs)),
+		minRatio: -1,
+	}
+	hooked := false
+	for _, br := range rs {
+		res.add(br)
+		switch br.(type) {
+		case OffsetSetter, MatchObserver, stopper, streamBytesReplacer:
+			hooked = true
 		}
-		if ratio > res.maxRatio {
-			res.maxRatio = ratio
+	}
+	if hooked {
+		return &hookedReplacer{replacer: res, winner: -1}
+	}
+	return res
+}
+
+// hookedReplacer is a composite of replacers some of which keep per stream state.
+// It forwards the hooks of StreamReplacingReader about a match to the replacer
+// that found it.
+type hookedReplacer struct {
+	*replacer
+	winner int // replacer of the match returned by the last Index call
+}
+
+func (h *hookedReplacer) Index(buf []byte) (resIndex int, resSearch []byte, resReplace []byte) {
+	resIndex, h.winner = -1, -1
+	for i, er := range h.replaces {
+		index, search, replace := er.Index(buf)
+		if index >= 0 && (resIndex == -1 || index < resIndex) {
+			resIndex, resSearch, resReplace = index, search, replace
+			h.winner = i
 		}
 	}
+	return
+}
+
+func (h *hookedReplacer) Matched(offset int64, search, replace []byte) {
+	if h.winner < 0 {
+		return
+	}
+	if mo, ok := h.replaces[h.winner].(MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+}
+
+func (h *hookedReplacer) stopAtMatch() bool {
+	if h.winner < 0 {
+		return false
+	}
+	s, ok := h.replaces[h.winner].(stopper)
+	return ok && s.stopAtMatch()
+}
+
+func (h *hookedReplacer) newBytesReplacer() BytesReplacer {
+	r := *h.replacer
+	r.replaces = make([]BytesReplacer, len(h.replaces))
+	for i, er := range h.replaces {
+		if sr, ok := er.(streamBytesReplacer); ok {
+			er = sr.newBytesReplacer()
+		}
+		r.replaces[i] = er
+	}
+	return &hookedReplacer{replacer: &r, winner: -1}
+}
+
+func (h *hookedReplacer) clone() (BytesReplacer, bool) {
+	r := *h.replacer
+	r.replaces = make([]BytesReplacer, len(h.replaces))
+	for i, er := range h.replaces {
+		c, ok := cloneBytesReplacer(er)
+		if !ok {
+			return h, false
+		}
+		r.replaces[i] = c
+	}
+	return &hookedReplacer{replacer: &r, winner: h.winner}, true
+}
+
+func (h *hookedReplacer) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, h.newBytesReplacer())
+}
+
+// ReplacePair is a search/replace pair for NewReplacerFromPairs and
+// NewReplacerOrdered.
+type ReplacePair struct {
+	Search   string
+	Replace  string
+	Priority int // only used by NewReplacerOrdered
+}
 
+// NewReplacerFromPairs is like NewReplacer but takes the pairs as a slice, which is
+// handier when they are built dynamically.
+func NewReplacerFromPairs(pairs []ReplacePair) Replacer {
+	res := &replacer{
+		replaces: make([]BytesReplacer, 0, len(pairs)),
+		minRatio: -1,
+	}
+	for _, p := range pairs {
+		if len(p.Search) == 0 { // search can not be empty
+			continue
+		}
+		res.add(&byteReplace{search: []byte(p.Search), replace: []byte(p.Replace)})
+	}
 	return res
 }
 
+// NewReplacerOrdered is like NewReplacerFromPairs but when several search tokens
+// match at the same position the pair with the highest Priority wins; among pairs
+// of equal Priority the one given first wins.
+func NewReplacerOrdered(pairs []ReplacePair) Replacer {
+	sorted := append([]ReplacePair(nil), pairs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return NewReplacerFromPairs(sorted)
+}
+
+func (r *replacer) add(br BytesReplacer) {
+	r.replaces = append(r.replaces, br)
+	searchLen, replaceLen, ratio := br.GetSizingHints()
+	if searchLen > r.maxSearchLen {
+		r.maxSearchLen = searchLen
+	}
+	if replaceLen > r.maxReplaceLen {
+		r.maxReplaceLen = replaceLen
+	}
+	// the buffer is sized for the token that expands the most.
+	if ratio > 0 && (r.minRatio < 0 || ratio < r.minRatio) {
+		r.minRatio = ratio
+	}
+}
+
 func (r *replacer) GetSizingHints() (int, int, float64) {
-	return r.maxSearchLen, r.maxReplaceLen, r.maxRatio
+	return r.maxSearchLen, r.maxReplaceLen, r.minRatio
 }
 
 func (r *replacer) Index(buf []byte) (resIndex int, resSearch []byte, resReplace []byte) {
@@ -202,6 +833,42 @@ func (r *replacer) Index(buf []byte) (resIndex int, resSearch []byte, resReplace
 	return
 }
 
+// SetOffset forwards the source offset to the replacers that need it.
+func (r *replacer) SetOffset(offset int64) {
+	for _, er := range r.replaces {
+		if os, ok := er.(OffsetSetter); ok {
+			os.SetOffset(offset)
+		}
+	}
+}
+
 func (r *replacer) Replace(src io.Reader) io.Reader {
 	return (&StreamReplacingReader{}).ResetEx(src, r)
 }
+
+// WouldReplace reports whether replacer finds a search token in input, that is
+// whether replacing input would change it, without doing the replacement.
+func WouldReplace(replacer BytesReplacer, input []byte) bool {
+	if os, ok := replacer.(OffsetSetter); ok {
+		os.SetOffset(0)
+	}
+	index, _, _ := replacer.Index(input)
+	return index >= 0
+}
+
+type syncReader struct {
+	mu sync.Mutex
+	r  *StreamReplacingReader
+}
+
+// NewSyncStreamReplacingReader is like StreamReplacingReader.ResetEx on a new reader
+// but returns a reader that can be read by several goroutines at once.
+func NewSyncStreamReplacingReader(r io.Reader, replacer BytesReplacer) io.Reader {
+	return &syncReader{r: (&StreamReplacingReader{}).ResetEx(r, replacer)}
+}
+
+func (s *syncReader) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Read(p)
+}
@@ -1,10 +1,13 @@
 package libio
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 )
 
 func TestReplacer(t *testing.T) {
@@ -22,3 +25,398 @@ func TestReplacer(t *testing.T) {
 	}
 
 }
+
+func TestStreamReplacingReader_Telemetry(t *testing.T) {
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader("foo bar foo"), NewReplacer("foo", "x").(BytesReplacer))
+	res, _ := io.ReadAll(r)
+
+	tm := r.Telemetry()
+	if tm.BytesIn != 11 || tm.BytesOut != int64(len(res)) || tm.BytesOut != 7 || tm.ReplacementsCount != 2 {
+		t.Errorf("unexpected telemetry %+v", tm)
+	}
+}
+
+func benchmarkReplacer(b *testing.B, search, replace string) {
+	content := strings.Repeat("some text around "+search+" and more text ", 4096)
+	r := NewReplacer(search, replace)
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = io.Copy(io.Discard, r.Replace(strings.NewReader(content)))
+	}
+}
+
+func BenchmarkReplacer_EqualLength(b *testing.B) {
+	benchmarkReplacer(b, "search", "values")
+}
+
+func BenchmarkReplacer_UnequalLength(b *testing.B) {
+	benchmarkReplacer(b, "search", "value")
+}
+
+func TestStreamReplacingReader_Reset(t *testing.T) {
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader("foo"), NewReplacer("foo", "bar").(BytesReplacer))
+	res, _ := io.ReadAll(r)
+	if string(res) != "bar" {
+		t.Fatalf("should bar but %s", res)
+	}
+
+	buf := r.buf
+	res, _ = io.ReadAll(r.Reset(strings.NewReader("a foo b")))
+	if string(res) != "a bar b" {
+		t.Errorf("should a bar b but %s", res)
+	}
+	if &buf[0] != &r.buf[0] {
+		t.Error("Reset should keep the buffer")
+	}
+}
+
+func FuzzStreamReplacingReader(f *testing.F) {
+	f.Add("zt zt ztztzt2zzt tzzzzzzzztzzzt", "zt", "zhangtian", "tzzzzzzzztzzzt", "zt2", 7)
+	f.Add(strings.Repeat("zt ztztzt2zzt abcab tzzzzzzzztzzzt bb ", 300), "zt", "zhangtian", "tzzzzzzzztzzzt", "zt2", 4096)
+	f.Add(strings.Repeat("a", 5000), "a", strings.Repeat("b", 300), "aa", "c", 512)
+	f.Add(strings.Repeat("ab", 3000), "b", "", "ab", "abc", 13)
+	f.Fuzz(func(t *testing.T, content, s1, r1, s2, r2 string, chunk int) {
+		if s1 == "" || s2 == "" {
+			t.Skip()
+		}
+		if chunk <= 0 || chunk > 1<<16 {
+			chunk = 4096
+		}
+		reader := NewReplacer(s1, r1, s2, r2).Replace(strings.NewReader(content))
+		var res []byte
+		p := make([]byte, chunk)
+		for {
+			n, err := reader.Read(p)
+			res = append(res, p[:n]...)
+			if err != nil {
+				break
+			}
+		}
+		expect := strings.NewReplacer(s1, r1, s2, r2).Replace(content)
+		if string(res) != expect {
+			t.Errorf("should %q but %q", expect, res)
+		}
+	})
+}
+
+func TestStreamReplacingReadWriter(t *testing.T) {
+	rw := NewStreamReplacingReadWriter(NewReplacer("foo", "bar").(BytesReplacer))
+
+	var res []byte
+	for _, s := range []string{"a fo", "o b f", "oo f"} {
+		if _, err := rw.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, b...)
+	}
+	_ = rw.Close()
+	b, _ := io.ReadAll(rw)
+	res = append(res, b...)
+
+	if string(res) != "a bar b bar f" {
+		t.Errorf("should a bar b bar f but %s", res)
+	}
+	if _, err := rw.Write([]byte("x")); err == nil {
+		t.Error("write after close should fail")
+	}
+}
+
+func TestSetDefaultBufSize(t *testing.T) {
+	defer SetDefaultBufSize(defaultBufSize)
+	SetDefaultBufSize(64 * 1024)
+
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader("foo"), NewReplacer("foo", "bar").(BytesReplacer))
+	if len(r.buf) != 64*1024 {
+		t.Errorf("buffer should be 64K but %d", len(r.buf))
+	}
+}
+
+func TestCompositeReplacer(t *testing.T) {
+	rr, err := NewReplacerBuilder().AddRegex(`[0-9]{2}`, "NN").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewCompositeReplacer(NewSmartCaseReplacer("foo", "bar"), rr.(BytesReplacer), NewReplacer("a1", "b").(BytesReplacer))
+	res, _ := io.ReadAll(r.Replace(strings.NewReader("Foo 12 a12 FOO")))
+	if string(res) != "Bar NN b2 BAR" {
+		t.Errorf("should Bar NN b2 BAR but %s", res)
+	}
+}
+
+func TestCompositeReplacer_Hooks(t *testing.T) {
+	// the match offsets, the field layout and the occurrence count reach the
+	// replacer that found the match.
+	or := NewOffsetAwareReplacer(NewReplacer("foo", "x").(BytesReplacer))
+	var src []byte
+	src = appendProtobufString(src, 1, "hello foo")
+	src = append(src, "qfoo"...)
+	r := NewCompositeReplacer(
+		NewReplacer("q", "Q").(BytesReplacer),
+		NewProtobufStringReplacer(1, "hello", "bye"),
+		or,
+	)
+	res, _ := io.ReadAll(r.Replace(bytes.NewReader(src)))
+	want := append(appendProtobufString(nil, 1, "bye foo"), "Qx"...)
+	if !bytes.Equal(res, want) {
+		t.Errorf("should %q but %q", want, res)
+	}
+	if off := or.LastMatchOffset(); off != int64(len(src)-3) {
+		t.Errorf("should report the match at %d but %d", len(src)-3, off)
+	}
+
+	fr := NewFieldReplacer(',', 1, NewReplacer("a", "b").(BytesReplacer))
+	r = NewCompositeReplacer(fr, NewReplacer("z", "Z").(BytesReplacer))
+	for i := 0; i < 2; i++ {
+		res, _ := io.ReadAll(r.Replace(strings.NewReader("a,a,z\n")))
+		if string(res) != "a,b,Z\n" {
+			t.Errorf("stream %d: should a,b,Z but %q", i, res)
+		}
+	}
+}
+
+func TestStreamReplacingReader_Clone(t *testing.T) {
+	content := strings.Repeat("foo bar ", 1000)
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader(content), NewReplacer("foo", "x").(BytesReplacer))
+	head := make([]byte, 10)
+	if _, err := io.ReadFull(r, head); err != nil {
+		t.Fatal(err)
+	}
+
+	c := r.Clone()
+	res1, _ := io.ReadAll(r)
+	res2, _ := io.ReadAll(c)
+	if string(res1) != string(res2) {
+		t.Error("clone should produce the same output")
+	}
+	if expect := strings.Repeat("x bar ", 1000); string(head)+string(res1) != expect {
+		t.Errorf("should %s but %s", expect, string(head)+string(res1))
+	}
+
+	// the clone keeps the data the original holds back, and its own write source.
+	w := NewStreamReplacingReadWriter(NewReplacer("foo", "x").(BytesReplacer))
+	_, _ = w.Write([]byte("a fo"))
+	head, _ = io.ReadAll(w)
+	c = w.Clone()
+	_, _ = c.Write([]byte("o b"))
+	_ = c.Close()
+	_ = w.Close()
+	res1, _ = io.ReadAll(w)
+	res2, _ = io.ReadAll(c)
+	if string(head) != "a " || string(res1) != "fo" || string(res2) != "x b" {
+		t.Errorf("unexpected outputs %q, %q and %q", head, res1, res2)
+	}
+
+	// the clone has its own count of occurrences.
+	content = "foo " + strings.Repeat("-", 2*defaultBufSize) + " foo foo"
+	r = NewNthOccurrenceReplacer("foo", "x", 2).Replace(strings.NewReader(content)).(*StreamReplacingReader)
+	head = make([]byte, 1)
+	_, _ = r.Read(head)
+	c = r.Clone()
+	res1, _ = io.ReadAll(r)
+	res2, _ = io.ReadAll(c)
+	if expect := strings.Replace(content, "foo foo", "x foo", 1); string(head)+string(res1) != expect || string(head)+string(res2) != expect {
+		t.Errorf("the original and the clone should replace the second occurrence")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("should panic on a source that cannot be read independently")
+		}
+	}()
+	NewStreamReplacingReader(iotest.OneByteReader(strings.NewReader(content)), NewReplacer("foo", "x").(BytesReplacer)).Clone()
+}
+
+func TestStreamReplacingReaderMaxExpansion(t *testing.T) {
+	replace := strings.Repeat("R", 256)
+	content := strings.Repeat("x", 3*defaultBufSize)
+	want := strings.Repeat(replace, len(content))
+
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader(content), NewReplacer("x", replace).(BytesReplacer))
+	bufLen := len(r.buf)
+	var res []byte
+	p := make([]byte, 1000)
+	for {
+		n, err := r.Read(p)
+		res = append(res, p[:n]...)
+		if len(r.buf) != bufLen || r.buf1 > len(r.buf) || r.buf0 > r.buf1 {
+			t.Fatalf("buffer out of bounds: len %d, was %d, buf0 %d, buf1 %d", len(r.buf), bufLen, r.buf0, r.buf1)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if string(res) != want {
+		t.Errorf("unexpected output of %d bytes, want %d", len(res), len(want))
+	}
+}
+
+func TestStreamReplacingReader_Discard(t *testing.T) {
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader("foo bar foo baz"), NewReplacer("foo", "x").(BytesReplacer))
+	n, err := r.Discard(6)
+	if n != 6 || err != nil {
+		t.Fatalf("unexpected discard %d %v", n, err)
+	}
+	res, _ := io.ReadAll(r)
+	if string(res) != "x baz" {
+		t.Errorf("should x baz but %s", res)
+	}
+
+	r.Reset(strings.NewReader("foo"))
+	if n, err = r.Discard(2); n != 1 || err != io.EOF {
+		t.Errorf("should 1 %v but %d %v", io.EOF, n, err)
+	}
+}
+
+func TestNewReplacerOrdered(t *testing.T) {
+	r := NewReplacerOrdered([]ReplacePair{
+		{Search: "foo", Replace: "1", Priority: 1},
+		{Search: "foobar", Replace: "2", Priority: 2},
+		{Search: "fo", Replace: "3", Priority: 1},
+	})
+	res, _ := io.ReadAll(r.Replace(strings.NewReader("foobar foo")))
+	if string(res) != "2 1" {
+		t.Errorf("should 2 1 but %s", res)
+	}
+}
+
+func TestWouldReplace(t *testing.T) {
+	br := NewReplacer("foo", "bar", "baz", "qux").(BytesReplacer)
+	if !WouldReplace(br, []byte("a baz")) {
+		t.Errorf("should find baz")
+	}
+	if WouldReplace(br, []byte("fo ba")) {
+		t.Errorf("should not find anything")
+	}
+}
+
+func TestSyncStreamReplacingReader(t *testing.T) {
+	content := strings.Repeat("foo bar ", 10000)
+	r := NewSyncStreamReplacingReader(strings.NewReader(content), NewReplacer("foo", "x").(BytesReplacer))
+
+	var mu sync.Mutex
+	total := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, _ := io.Copy(io.Discard, r)
+			mu.Lock()
+			total += int(n)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if want := len(strings.ReplaceAll(content, "foo", "x")); total != want {
+		t.Errorf("should read %d bytes but %d", want, total)
+	}
+}
+
+func TestNewReplacerFromPairs(t *testing.T) {
+	r := NewReplacerFromPairs([]ReplacePair{{Search: "foo", Replace: "1"}, {Search: "foobar", Replace: "2"}})
+	res, _ := io.ReadAll(r.Replace(strings.NewReader("foobar")))
+	if string(res) != "1bar" {
+		t.Errorf("should 1bar but %s", res)
+	}
+}
+
+func TestStreamReplacingReader_WithOverlapHint(t *testing.T) {
+	br := NewReplacer("foo", "bar", "foo-long-token", "x").(BytesReplacer)
+	r := NewStreamReplacingReader(strings.NewReader("aaaaaaaaaaaaaaaaaaaa"), br, WithOverlapHint(3))
+	r.fill()
+	if held := r.buf1 - r.buf0; held != 2 {
+		t.Errorf("should hold back 2 bytes but %d", held)
+	}
+
+	r = NewStreamReplacingReader(iotest.OneByteReader(strings.NewReader("a foo b foo")), br, WithOverlapHint(3))
+	res, _ := io.ReadAll(r)
+	if string(res) != "a bar b bar" {
+		t.Errorf("should a bar b bar but %s", res)
+	}
+}
+
+func TestLargeSearchToken(t *testing.T) {
+	search := strings.Repeat("s", 1<<20)
+	content := "a" + search + "b" + search[:1000] + "c" + search
+	r := (&StreamReplacingReader{}).ResetEx(iotest.HalfReader(strings.NewReader(content)), NewReplacer(search, "X").(BytesReplacer))
+	if len(r.buf) < len(search) {
+		t.Fatalf("buffer of %d bytes cannot hold the search token", len(r.buf))
+	}
+	res, err := io.ReadAll(r)
+	if want := "aXb" + search[:1000] + "cX"; err != nil || string(res) != want {
+		t.Errorf("unexpected output of %d bytes, want %d: %v", len(res), len(want), err)
+	}
+}
+
+func TestLongTokensSmallRatio(t *testing.T) {
+	b, c := strings.Repeat("b", 3000), strings.Repeat("c", 3000)
+	content := strings.Repeat("a", 5000) + c + "xa" + c
+	br := NewReplacer("a", b, c, "d").(BytesReplacer)
+	r := (&StreamReplacingReader{}).ResetEx(iotest.HalfReader(strings.NewReader(content)), br)
+	if want := defaultBufSize + 2*3000; len(r.buf) > want {
+		t.Errorf("buffer should be at most %d bytes but %d", want, len(r.buf))
+	}
+	res, err := io.ReadAll(r)
+	if want := strings.NewReplacer("a", b, c, "d").Replace(content); err != nil || string(res) != want {
+		t.Errorf("unexpected output of %d bytes, want %d: %v", len(res), len(want), err)
+	}
+}
+
+func TestWrappingReplacer(t *testing.T) {
+	res, _ := io.ReadAll(NewWrappingReplacer("TODO", "[", "]").Replace(strings.NewReader("TODO: fix TODO")))
+	if string(res) != "[TODO]: fix [TODO]" {
+		t.Errorf("should [TODO]: fix [TODO] but %s", res)
+	}
+}
+
+func TestStreamReplacingReaderLen(t *testing.T) {
+	src := "a bcd a"
+	r := NewReplacer("a", "xyz", "bcd", "").(BytesReplacer)
+	rr := NewStreamReplacingReader(strings.NewReader(src), r)
+	if n := rr.Len(); n != 3*int64(len(src)) {
+		t.Errorf("should bound the output by %d but %d", 3*len(src), n)
+	}
+	buf := make([]byte, 3)
+	n, _ := rr.Read(buf)
+	if l := rr.Len(); l < int64(len("xyz  xyz")-n) {
+		t.Errorf("bound %d is below the %d bytes left", l, len("xyz  xyz")-n)
+	}
+	res, _ := io.ReadAll(rr)
+	if string(buf[:n])+string(res) != "xyz  xyz" {
+		t.Errorf("Len should not change the output: %q", string(buf[:n])+string(res))
+	}
+	if l := rr.Len(); l != 0 {
+		t.Errorf("should have nothing left but %d", l)
+	}
+
+	if l := NewStreamReplacingReader(iotest.OneByteReader(strings.NewReader(src)), r).Len(); l != -1 {
+		t.Errorf("should not know the length of a non seeker source but %d", l)
+	}
+}
+
+func TestNewReplacerCompiled(t *testing.T) {
+	r, err := NewReplacerCompiled("foo", "1", "bar", "2", "", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := io.ReadAll(r.Replace(strings.NewReader("foobar"))); string(res) != "12" {
+		t.Errorf("should %q but %q", "12", res)
+	}
+
+	for _, oldnews := range [][]string{{"fo", "1", "bar", "2", "foo", "3"}, {"a", "1", "a", "2"}, {"a"}} {
+		if _, err := NewReplacerCompiled(oldnews...); err == nil {
+			t.Errorf("should reject %q", oldnews)
+		}
+	}
+	if _, err := NewReplacerCompiled("fo", "1", "foo", "3"); err == nil || !strings.Contains(err.Error(), `"fo" is a prefix of "foo"`) {
+		t.Errorf("should name the conflicting tokens: %v", err)
+	}
+}
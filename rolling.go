@@ -0,0 +1,77 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool/ladder"
+	"io"
+)
+
+const (
+	adlerMod = 65521
+	// a chunk boundary is found where the low 13 bits of the checksum are all zero,
+	// which gives chunks of about 8KB on random data.
+	rollingChecksumMask = 1<<13 - 1
+)
+
+type rollingChecksumReader struct {
+	r      io.Reader
+	fn     func(offset int64, checksum uint32)
+	window []byte // ring of the last len(window) bytes
+	pooled bool
+	pos    int   // next position to write in window
+	offset int64 // number of bytes read
+	a, b   uint32
+}
+
+// NewRollingChecksumReader returns a reader of src that maintains the Adler-32
+// checksum of the last windowSize bytes read and calls fn with the offset just
+// past the window and the checksum whenever the window is at a chunk boundary, as
+// used for content defined chunking. The data itself is passed through unchanged
+// and fn is called before Read returns it.
+func NewRollingChecksumReader(src io.Reader, windowSize int, fn func(offset int64, checksum uint32)) io.Reader {
+	if windowSize <= 0 {
+		panic("libio.NewRollingChecksumReader: window size must be positive")
+	}
+	rc := &rollingChecksumReader{r: src, fn: fn, a: 1}
+	if rc.window = ladder.Get(windowSize); rc.window != nil {
+		rc.pooled = true
+	} else {
+		rc.window = make([]byte, windowSize)
+	}
+	return rc
+}
+
+func (rc *rollingChecksumReader) Read(p []byte) (int, error) {
+	if rc.window == nil {
+		return rc.r.Read(p)
+	}
+	n, err := rc.r.Read(p)
+	size := uint32(len(rc.window))
+	for _, in := range p[:n] {
+		if rc.offset < int64(size) {
+			rc.a = (rc.a + uint32(in)) % adlerMod
+			rc.b = (rc.b + rc.a) % adlerMod
+		} else {
+			out := uint32(rc.window[rc.pos])
+			rc.a = (rc.a + adlerMod - out + uint32(in)) % adlerMod
+			rc.b = (rc.b + adlerMod - size*out%adlerMod + rc.a + adlerMod - 1) % adlerMod
+		}
+		rc.window[rc.pos] = in
+		rc.pos++
+		if rc.pos == len(rc.window) {
+			rc.pos = 0
+		}
+		rc.offset++
+		if rc.offset >= int64(size) {
+			if sum := rc.b<<16 | rc.a; sum&rollingChecksumMask == 0 {
+				rc.fn(rc.offset, sum)
+			}
+		}
+	}
+	if err != nil {
+		if rc.pooled {
+			_ = ladder.Put(rc.window)
+		}
+		rc.window = nil
+	}
+	return n, err
+}
@@ -0,0 +1,34 @@
+package libio
+
+import (
+	"bytes"
+	"hash/adler32"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRollingChecksumReader(t *testing.T) {
+	data := make([]byte, 256*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	const window = 64
+	var boundaries int
+	r := NewRollingChecksumReader(iotest.HalfReader(bytes.NewReader(data)), window, func(offset int64, checksum uint32) {
+		boundaries++
+		if want := adler32.Checksum(data[offset-window : offset]); checksum != want {
+			t.Fatalf("checksum at %d should %x but %x", offset, want, checksum)
+		}
+		if checksum&rollingChecksumMask != 0 {
+			t.Fatalf("checksum %x at %d is not a boundary", checksum, offset)
+		}
+	})
+	res, err := io.ReadAll(r)
+	if err != nil || len(res) != len(data) {
+		t.Fatalf("unexpected result %d %v", len(res), err)
+	}
+	if boundaries == 0 {
+		t.Errorf("no boundary found")
+	}
+}
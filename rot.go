@@ -0,0 +1,74 @@
+package libio
+
+import "io"
+
+var rot13 = NewROTNReplacer(13)
+
+// NewROT13Reader returns a reader of src with ASCII letters rotated by 13
+// positions, which is its own inverse.
+func NewROT13Reader(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, rot13)
+}
+
+// NewROTNReader returns a reader of src with ASCII letters rotated by n positions
+// in the alphabet, keeping their case; NewROTNReader(r, -n) undoes it.
+func NewROTNReader(src io.Reader, n int) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, NewROTNReplacer(n))
+}
+
+// NewROTNReplacer returns a BytesReplacer rotating ASCII letters by n positions
+// in the alphabet, keeping their case, each letter being a single byte token.
+func NewROTNReplacer(n int) BytesReplacer {
+	n = (n%26 + 26) % 26
+	r := &rotReplace{}
+	for i := range r.table {
+		r.table[i] = byte(i)
+	}
+	for i := 0; i < 26; i++ {
+		j := (i + n) % 26
+		r.table['a'+i] = byte('a' + j)
+		r.table['A'+i] = byte('A' + j)
+	}
+	return r
+}
+
+// rotReplace replaces every byte b with table[b], letters being the only bytes
+// table changes.
+type rotReplace struct {
+	table [256]byte
+}
+
+func (r *rotReplace) GetSizingHints() (int, int, float64) {
+	return 1, 1, -1
+}
+
+func (r *rotReplace) Index(buf []byte) (int, []byte, []byte) {
+	for i, c := range buf {
+		if r.table[c] != c {
+			return i, buf[i : i+1], r.table[int(c) : int(c)+1]
+		}
+	}
+	return -1, nil, nil
+}
+
+func (r *rotReplace) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, r)
+}
+
+// rot47 maps the printable ASCII characters '!' to '~' to the one 47 positions
+// further, wrapping around.
+var rot47 = func() (t [256]byte) {
+	for i := range t {
+		t[i] = byte(i)
+		if i >= '!' && i <= '~' {
+			t[i] = byte('!' + (i-'!'+47)%94)
+		}
+	}
+	return
+}()
+
+// NewROT47Reader returns a reader of src with printable ASCII characters other
+// than the space rotated by 47 positions, which is its own inverse.
+func NewROT47Reader(src io.Reader) io.Reader {
+	return NewLookupTableReader(src, rot47)
+}
@@ -0,0 +1,48 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestROTNReader(t *testing.T) {
+	res, _ := io.ReadAll(NewROT13Reader(strings.NewReader("Hello, World!")))
+	if string(res) != "Uryyb, Jbeyq!" {
+		t.Errorf("should Uryyb, Jbeyq! but %s", res)
+	}
+
+	res, _ = io.ReadAll(NewROTNReader(NewROTNReader(strings.NewReader("Zebra xyz"), 3), -3))
+	if string(res) != "Zebra xyz" {
+		t.Errorf("should Zebra xyz but %s", res)
+	}
+}
+
+func TestROTNReplacer(t *testing.T) {
+	// the rotation mixes with other rules, the one given first wins a tie.
+	r := NewCompositeReplacer(NewReplacer("Hello", "Bye").(BytesReplacer), NewROTNReplacer(13))
+	res, _ := io.ReadAll(r.Replace(strings.NewReader("Hello, World!")))
+	if string(res) != "Bye, Jbeyq!" {
+		t.Errorf("should Bye, Jbeyq! but %s", res)
+	}
+}
+
+func TestROT47Reader(t *testing.T) {
+	res, _ := io.ReadAll(NewROT47Reader(strings.NewReader("Hello, World!")))
+	if string(res) != "w6==@[ (@C=5P" {
+		t.Errorf("should w6==@[ (@C=5P but %s", res)
+	}
+
+	res, _ = io.ReadAll(NewROT47Reader(NewROT47Reader(strings.NewReader("a ~é"))))
+	if string(res) != "a ~é" {
+		t.Errorf("should a ~é but %s", res)
+	}
+}
+
+func TestROT13Reader_Large(t *testing.T) {
+	content := strings.Repeat("Hello, World! ", 100000)
+	res, err := io.ReadAll(NewROT13Reader(NewROT13Reader(strings.NewReader(content))))
+	if err != nil || string(res) != content {
+		t.Errorf("should read %d bytes back but %d %v", len(content), len(res), err)
+	}
+}
@@ -0,0 +1,39 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+type runeReplace struct {
+	search  []byte
+	replace []byte
+}
+
+// NewRuneReplacer returns a BytesReplacer that replaces the UTF-8 encoding of
+// search with replace. As UTF-8 is self-synchronizing, a match is always a whole
+// code point of valid UTF-8 text.
+func NewRuneReplacer(search rune, replace string) BytesReplacer {
+	if !utf8.ValidRune(search) {
+		panic("libio.NewRuneReplacer: invalid rune")
+	}
+	return &runeReplace{search: []byte(string(search)), replace: []byte(replace)}
+}
+
+func (r *runeReplace) GetSizingHints() (int, int, float64) {
+	replaceLen := len(r.replace)
+	ratio := float64(-1)
+	if len(r.search) < replaceLen {
+		ratio = float64(len(r.search)) / float64(replaceLen)
+	}
+	return utf8.UTFMax, replaceLen, ratio
+}
+
+func (r *runeReplace) Index(buf []byte) (int, []byte, []byte) {
+	return bytes.Index(buf, r.search), r.search, r.replace
+}
+
+func (r *runeReplace) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, r)
+}
@@ -0,0 +1,17 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRuneReplacer(t *testing.T) {
+	src := "“quoted” — “again”"
+	r := NewCompositeReplacer(NewRuneReplacer('“', `"`), NewRuneReplacer('”', `"`))
+	res, _ := io.ReadAll(r.Replace(iotest.OneByteReader(strings.NewReader(src))))
+	if want := `"quoted" — "again"`; string(res) != want {
+		t.Errorf("should %s but %s", want, res)
+	}
+}
@@ -0,0 +1,73 @@
+package libio
+
+import (
+	"io"
+	"sync"
+)
+
+// NewSegmentCountReader returns a reader of src as is, along with a function
+// returning the lengths of the segments of src between occurrences of sep, not
+// counting sep itself. Occurrences are found without overlaps, and like
+// bytes.Split the segments of a source with n separators number n+1, the last one
+// being only counted once the reader returned io.EOF. The function is safe to call
+// concurrently with Read.
+func NewSegmentCountReader(src io.Reader, sep []byte) (io.Reader, func() []int64) {
+	if len(sep) == 0 {
+		panic("libio.NewSegmentCountReader: separator cannot be empty")
+	}
+	s := &segmentCountReader{r: src, sep: sep, fail: make([]int, len(sep))}
+	// fail[i] is the length of the longest proper prefix of sep[:i+1] that is also
+	// a suffix of it.
+	for i, k := 1, 0; i < len(sep); i++ {
+		for k > 0 && sep[i] != sep[k] {
+			k = s.fail[k-1]
+		}
+		if sep[i] == sep[k] {
+			k++
+		}
+		s.fail[i] = k
+	}
+	return s, s.segments
+}
+
+type segmentCountReader struct {
+	r       io.Reader
+	sep     []byte
+	fail    []int
+	matched int   // length of the prefix of sep ending the data read so far
+	cur     int64 // bytes of the current segment, including the matched prefix
+	done    bool  // the last segment was recorded at io.EOF
+	mu      sync.Mutex
+	lens    []int64
+}
+
+func (s *segmentCountReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range p[:n] {
+		for s.matched > 0 && b != s.sep[s.matched] {
+			s.matched = s.fail[s.matched-1]
+		}
+		if b == s.sep[s.matched] {
+			s.matched++
+		}
+		s.cur++
+		if s.matched == len(s.sep) {
+			s.lens = append(s.lens, s.cur-int64(len(s.sep)))
+			s.cur, s.matched = 0, 0
+		}
+	}
+	if err == io.EOF && !s.done {
+		s.lens = append(s.lens, s.cur)
+		s.cur, s.matched = 0, 0
+		s.done = true
+	}
+	return n, err
+}
+
+func (s *segmentCountReader) segments() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.lens...)
+}
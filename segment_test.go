@@ -0,0 +1,42 @@
+package libio
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSegmentCountReader(t *testing.T) {
+	src := "abc||d|||||ef||"
+	r, segments := NewSegmentCountReader(iotest.OneByteReader(strings.NewReader(src)), []byte("||"))
+	res, err := io.ReadAll(r)
+	if err != nil || string(res) != src {
+		t.Fatalf("should read the source as is but %q %v", res, err)
+	}
+	var want []int64
+	for _, s := range strings.Split(src, "||") {
+		want = append(want, int64(len(s)))
+	}
+	if got := segments(); !reflect.DeepEqual(got, want) {
+		t.Errorf("should %v but %v", want, got)
+	}
+
+	r, segments = NewSegmentCountReader(strings.NewReader("aab"), []byte("ab"))
+	_, _ = io.ReadAll(r)
+	if got := segments(); !reflect.DeepEqual(got, []int64{1, 0}) {
+		t.Errorf("should find a separator after a partial match but %v", got)
+	}
+
+	r, segments = NewSegmentCountReader(strings.NewReader("ab,c"), []byte(","))
+	_, _ = io.ReadAll(r)
+	for i := 0; i < 2; i++ {
+		if n, err := r.Read(make([]byte, 4)); n != 0 || err != io.EOF {
+			t.Fatalf("should keep returning io.EOF but %d %v", n, err)
+		}
+	}
+	if got := segments(); !reflect.DeepEqual(got, []int64{2, 1}) {
+		t.Errorf("should record the last segment once but %v", got)
+	}
+}
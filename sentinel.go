@@ -0,0 +1,75 @@
+package libio
+
+import "bytes"
+
+// stopper is implemented by BytesReplacers that can end the stream: after calling
+// Matched, StreamReplacingReader calls stopAtMatch and, if it returns true, ends
+// its output right before the match.
+type stopper interface {
+	stopAtMatch() bool
+}
+
+// NewSentinelStoppingReplacer returns a BytesReplacer that replaces like inner
+// until the first occurrence of sentinel, where StreamReplacingReader returns
+// io.EOF. Neither the sentinel nor what follows it is delivered; the part of the
+// source after the data already read by the reader is left unread. A sentinel
+// overlapping a match of inner only stops the stream if it starts first. The
+// replacer tracks a single stream at a time.
+func NewSentinelStoppingReplacer(sentinel string, inner BytesReplacer) BytesReplacer {
+	if len(sentinel) == 0 {
+		panic("libio.NewSentinelStoppingReplacer: sentinel cannot be empty")
+	}
+	return &sentinelReplacer{inner: inner, sentinel: []byte(sentinel)}
+}
+
+type sentinelReplacer struct {
+	inner    BytesReplacer
+	sentinel []byte
+	found    bool // the last Index returned the sentinel
+	stop     bool
+}
+
+func (s *sentinelReplacer) GetSizingHints() (int, int, float64) {
+	maxSearch, maxReplace, ratio := s.inner.GetSizingHints()
+	return max(maxSearch, len(s.sentinel)), max(maxReplace, len(s.sentinel)), ratio
+}
+
+func (s *sentinelReplacer) SetOffset(offset int64) {
+	if os, ok := s.inner.(OffsetSetter); ok {
+		os.SetOffset(offset)
+	}
+}
+
+func (s *sentinelReplacer) Index(buf []byte) (int, []byte, []byte) {
+	index, search, replace := s.inner.Index(buf)
+	si := bytes.Index(buf, s.sentinel)
+	s.found = si >= 0 && (index < 0 || si <= index)
+	if s.found {
+		// replaced by itself, the reader stops before it anyway.
+		return si, s.sentinel, s.sentinel
+	}
+	return index, search, replace
+}
+
+func (s *sentinelReplacer) Matched(offset int64, search, replace []byte) {
+	if s.found {
+		s.stop = true
+		return
+	}
+	if mo, ok := s.inner.(MatchObserver); ok {
+		mo.Matched(offset, search, replace)
+	}
+}
+
+func (s *sentinelReplacer) clone() (BytesReplacer, bool) {
+	c := *s
+	var ok bool
+	c.inner, ok = cloneBytesReplacer(s.inner)
+	return &c, ok
+}
+
+func (s *sentinelReplacer) stopAtMatch() bool {
+	stop := s.stop
+	s.stop = false
+	return stop
+}
@@ -0,0 +1,48 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSentinelStoppingReplacer(t *testing.T) {
+	inner := &byteReplace{search: []byte("a"), replace: []byte("AA")}
+	for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.OneByteReader} {
+		r := NewStreamReplacingReader(wrap(strings.NewReader("abca<END>abc")), NewSentinelStoppingReplacer("<END>", inner))
+		res, err := io.ReadAll(r)
+		if err != nil || string(res) != "AAbcAA" {
+			t.Errorf("should %q but %q %v", "AAbcAA", res, err)
+		}
+		if n := r.Telemetry().ReplacementsCount; n != 2 {
+			t.Errorf("should count 2 replacements but %d", n)
+		}
+	}
+
+	res, _ := io.ReadAll(NewStreamReplacingReader(strings.NewReader("abc"), NewSentinelStoppingReplacer("<END>", inner)))
+	if string(res) != "AAbc" {
+		t.Errorf("should replace the whole stream without sentinel but %q", res)
+	}
+}
+
+func TestSentinelStoppingReplacer_Wrapped(t *testing.T) {
+	inner := &byteReplace{search: []byte("a"), replace: []byte("AA")}
+	counts, counting := NewCountingReplacer(NewCompositeReplacer(NewSentinelStoppingReplacer("<END>", inner)))
+	for _, c := range []struct {
+		name string
+		r    Replacer
+		want string
+	}{
+		{"composite", NewCompositeReplacer(NewSentinelStoppingReplacer("<END>", inner), NewReplacer("c", "C").(BytesReplacer)), "AAbCAA"},
+		{"counting", counting, "AAbcAA"},
+	} {
+		res, err := io.ReadAll(c.r.Replace(strings.NewReader("abca<END>abc")))
+		if err != nil || string(res) != c.want {
+			t.Errorf("%s: should %q but %q %v", c.name, c.want, res, err)
+		}
+	}
+	if n := counts.Counts()["a"]; n != 2 {
+		t.Errorf("should count 2 replacements but %d", n)
+	}
+}
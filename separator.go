@@ -0,0 +1,23 @@
+package libio
+
+import "io"
+
+// NewChunkSeparatorReader returns a reader of src with separator inserted after
+// every chunkSize bytes, which makes buffer boundaries visible when debugging a
+// pipeline.
+func NewChunkSeparatorReader(src io.Reader, chunkSize int, separator []byte) io.Reader {
+	if chunkSize <= 0 {
+		panic("libio.NewChunkSeparatorReader: chunk size must be positive")
+	}
+	left := chunkSize // bytes until the next separator
+	return newTransformReader(src, func(dst, p []byte) []byte {
+		for len(p) >= left {
+			dst = append(dst, p[:left]...)
+			dst = append(dst, separator...)
+			p = p[left:]
+			left = chunkSize
+		}
+		left -= len(p)
+		return append(dst, p...)
+	}, nil)
+}
@@ -0,0 +1,15 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestChunkSeparatorReader(t *testing.T) {
+	res, err := io.ReadAll(NewChunkSeparatorReader(iotest.HalfReader(strings.NewReader("abcdefgh")), 3, []byte("|")))
+	if err != nil || string(res) != "abc|def|gh" {
+		t.Errorf("should abc|def|gh but %s %v", res, err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build (amd64 || arm64) && !purego
+
+package libio
+
+// indexByteSIMD returns the index of the first c in b, -1 if there is none. It is
+// implemented in simd_index_$GOARCH.s.
+//
+//go:noescape
+func indexByteSIMD(b []byte, c byte) int
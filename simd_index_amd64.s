@@ -0,0 +1,55 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func indexByteSIMD(b []byte, c byte) int
+// Compares 16 bytes at a time with SSE2, which every amd64 CPU has and is all a
+// single byte search needs, then the tail byte by byte.
+TEXT ·indexByteSIMD(SB), NOSPLIT, $0-40
+	MOVQ   b_base+0(FP), SI
+	MOVQ   b_len+8(FP), BX
+	MOVBLZX c+24(FP), AX
+	MOVQ   SI, DI
+
+	// broadcast c to the 16 bytes of X0.
+	MOVQ      AX, X0
+	PUNPCKLBW X0, X0
+	PUNPCKLBW X0, X0
+	PSHUFL    $0, X0, X0
+
+loop16:
+	CMPQ     BX, $16
+	JB       tail
+	MOVOU    (SI), X1
+	PCMPEQB  X0, X1
+	PMOVMSKB X1, DX
+	TESTL    DX, DX
+	JNZ      found16
+	ADDQ     $16, SI
+	SUBQ     $16, BX
+	JMP      loop16
+
+found16:
+	BSFL DX, DX
+	SUBQ DI, SI
+	ADDQ DX, SI
+	MOVQ SI, ret+32(FP)
+	RET
+
+tail:
+	TESTQ BX, BX
+	JZ    notfound
+	CMPB  (SI), AL
+	JEQ   found
+	INCQ  SI
+	DECQ  BX
+	JMP   tail
+
+found:
+	SUBQ DI, SI
+	MOVQ SI, ret+32(FP)
+	RET
+
+notfound:
+	MOVQ $-1, ret+32(FP)
+	RET
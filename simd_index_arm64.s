@@ -0,0 +1,58 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func indexByteSIMD(b []byte, c byte) int
+// Compares 16 bytes at a time with NEON, then the tail byte by byte.
+TEXT ·indexByteSIMD(SB), NOSPLIT, $0-40
+	MOVD  b_base+0(FP), R0
+	MOVD  b_len+8(FP), R1
+	MOVBU c+24(FP), R2
+	MOVD  R0, R3
+	VDUP  R2, V0.B16
+
+loop16:
+	CMP    $16, R1
+	BLT    tail
+	VLD1.P 16(R0), [V1.B16]
+	VCMEQ  V0.B16, V1.B16, V2.B16
+	VMOV   V2.D[0], R4
+	VMOV   V2.D[1], R5
+	ORR    R4, R5, R6
+	CBNZ   R6, found16
+	SUB    $16, R1, R1
+	B      loop16
+
+found16:
+	// R0 is past the 16 bytes compared, the matching lanes are 0xff in R4:R5.
+	SUB  $16, R0, R0
+	CBNZ R4, low
+	ADD  $8, R0, R0
+	MOVD R5, R4
+
+low:
+	RBIT R4, R4
+	CLZ  R4, R4
+	ADD  R4>>3, R0, R0
+	SUB  R3, R0, R0
+	MOVD R0, ret+32(FP)
+	RET
+
+tail:
+	CBZ     R1, notfound
+	MOVBU.P 1(R0), R5
+	CMP     R2, R5
+	BEQ     found
+	SUB     $1, R1, R1
+	B       tail
+
+found:
+	SUB  $1, R0, R0
+	SUB  R3, R0, R0
+	MOVD R0, ret+32(FP)
+	RET
+
+notfound:
+	MOVD $-1, R0
+	MOVD R0, ret+32(FP)
+	RET
@@ -0,0 +1,10 @@
+//go:build !(amd64 || arm64) || purego
+
+package libio
+
+import "bytes"
+
+// indexByteSIMD returns the index of the first c in b, -1 if there is none.
+func indexByteSIMD(b []byte, c byte) int {
+	return bytes.IndexByte(b, c)
+}
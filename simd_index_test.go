@@ -0,0 +1,30 @@
+package libio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIndexByteSIMD(t *testing.T) {
+	buf := make([]byte, 100)
+	for n := 0; n <= len(buf); n++ {
+		for pos := -1; pos < n; pos++ {
+			for i := range buf {
+				buf[i] = 'a'
+			}
+			if pos >= 0 {
+				buf[pos] = 'x'
+			}
+			// offset the slice to test unaligned starts too.
+			for _, off := range []int{0, 1} {
+				if off > n {
+					continue
+				}
+				b := buf[off:n]
+				if got, want := indexByteSIMD(b, 'x'), bytes.IndexByte(b, 'x'); got != want {
+					t.Fatalf("len %d pos %d off %d: should %d but %d", n, pos, off, want, got)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package libio
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+type smartCaseReplace struct {
+	search  []byte
+	replace []byte
+	lower   []byte
+	upper   []byte
+	title   []byte
+}
+
+// NewSmartCaseReplacer returns a BytesReplacer that matches search regardless of
+// case and adapts the case of replace to the match: an all-uppercase match is
+// replaced by the uppercase replace, an all-lowercase match by the lowercase one,
+// a title-case match by the title-case one, and any other match by replace as is.
+func NewSmartCaseReplacer(search, replace string) BytesReplacer {
+	if len(search) == 0 {
+		panic("libio.NewSmartCaseReplacer: search token cannot be empty")
+	}
+	lower := bytes.ToLower([]byte(replace))
+	title := append([]byte{}, lower...)
+	if r, size := utf8.DecodeRune(lower); size > 0 {
+		title = append([]byte(string(unicode.ToUpper(r))), lower[size:]...)
+	}
+	return &smartCaseReplace{
+		search:  []byte(search),
+		replace: []byte(replace),
+		lower:   lower,
+		upper:   bytes.ToUpper([]byte(replace)),
+		title:   title,
+	}
+}
+
+func (s *smartCaseReplace) GetSizingHints() (int, int, float64) {
+	searchLen := len(s.search)
+	replaceLen := max(max(len(s.replace), len(s.lower)), max(len(s.upper), len(s.title)))
+	ratio := float64(-1)
+	if searchLen < replaceLen {
+		ratio = float64(searchLen) / float64(replaceLen)
+	}
+	return searchLen, replaceLen, ratio
+}
+
+func (s *smartCaseReplace) Index(buf []byte) (int, []byte, []byte) {
+	index := foldIndex(buf, s.search)
+	if index < 0 {
+		return -1, nil, nil
+	}
+	match := buf[index : index+len(s.search)]
+	lower, upper := bytes.ToLower(match), bytes.ToUpper(match)
+	switch {
+	case bytes.Equal(match, upper) && !bytes.Equal(match, lower):
+		return index, match, s.upper
+	case bytes.Equal(match, lower) && !bytes.Equal(match, upper):
+		return index, match, s.lower
+	}
+	if r, size := utf8.DecodeRune(match); unicode.IsUpper(r) && bytes.Equal(match[size:], lower[size:]) {
+		return index, match, s.title
+	}
+	return index, match, s.replace
+}
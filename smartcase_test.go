@@ -0,0 +1,16 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSmartCaseReplacer(t *testing.T) {
+	sr := NewSmartCaseReplacer("foo", "bar")
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader("foo FOO Foo fOo"), sr)
+	res, _ := io.ReadAll(r)
+	if string(res) != "bar BAR Bar bar" {
+		t.Errorf("should bar BAR Bar bar but %s", res)
+	}
+}
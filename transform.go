@@ -0,0 +1,51 @@
+package libio
+
+import (
+	"github.com/eleztian/pipe/bytespool/ladder"
+	"io"
+)
+
+const transformBufSize = 4096
+
+// transformReader is the base of the readers implemented as a state machine over
+// the bytes of their source rather than on StreamReplacingReader. step appends the
+// transformation of the next bytes of the source to dst and returns it; flush, if
+// not nil, appends what is left once the source is exhausted. The read buffer is
+// taken from the ladder pool and given back at the end of the stream.
+type transformReader struct {
+	r     io.Reader
+	step  func(dst, p []byte) []byte
+	flush func(dst []byte) []byte
+	buf   []byte
+	out   []byte // out[off:] is transformed but not delivered yet
+	off   int
+	err   error
+}
+
+func newTransformReader(src io.Reader, step func(dst, p []byte) []byte, flush func(dst []byte) []byte) *transformReader {
+	return &transformReader{r: src, step: step, flush: flush}
+}
+
+func (t *transformReader) Read(p []byte) (int, error) {
+	for t.off == len(t.out) {
+		if t.err != nil {
+			if t.buf != nil {
+				_ = ladder.Put(t.buf)
+				t.buf = nil
+			}
+			return 0, t.err
+		}
+		if t.buf == nil {
+			t.buf = ladder.Get(transformBufSize)
+		}
+		var n int
+		n, t.err = t.r.Read(t.buf)
+		t.out, t.off = t.step(t.out[:0], t.buf[:n]), 0
+		if t.err != nil && t.flush != nil {
+			t.out = t.flush(t.out)
+		}
+	}
+	n := copy(p, t.out[t.off:])
+	t.off += n
+	return n, nil
+}
@@ -0,0 +1,62 @@
+package libio
+
+import "io"
+
+// TruncatingOption configures the reader returned by NewTruncatingReader.
+type TruncatingOption func(*truncatingReader)
+
+// WithTruncationMarker makes the reader append marker after the first maxBytes
+// bytes when src is truncated, e.g. "...[truncated]".
+func WithTruncationMarker(marker []byte) TruncatingOption {
+	return func(t *truncatingReader) {
+		t.marker = marker
+	}
+}
+
+type truncatingReader struct {
+	r         io.Reader
+	left      int64
+	marker    []byte // left to deliver once truncated
+	done      bool   // the end of the output is known
+	truncated bool
+}
+
+// NewTruncatingReader returns a reader of at most the first maxBytes bytes of src.
+// If src is longer, the truncation marker set by WithTruncationMarker, if any, is
+// appended. Telling whether src is longer takes reading one more byte from it.
+func NewTruncatingReader(src io.Reader, maxBytes int64, opts ...TruncatingOption) io.Reader {
+	t := &truncatingReader{r: src, left: maxBytes}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *truncatingReader) Read(p []byte) (int, error) {
+	if t.left > 0 && !t.done {
+		if int64(len(p)) > t.left {
+			p = p[:t.left]
+		}
+		n, err := t.r.Read(p)
+		t.left -= int64(n)
+		if err == io.EOF {
+			t.done = true
+		}
+		return n, err
+	}
+	if !t.done {
+		var b [1]byte
+		n, err := io.ReadFull(t.r, b[:])
+		if n == 0 && err != io.EOF {
+			return 0, err
+		}
+		t.done = true
+		t.truncated = n > 0
+	}
+	if t.truncated && len(t.marker) > 0 {
+		n := copy(p, t.marker)
+		t.marker = t.marker[n:]
+		return n, nil
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,31 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTruncatingReader(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{"hello world", "hello[...]"},
+		{"hello", "hello"},
+		{"hi", "hi"},
+	}
+	for _, c := range cases {
+		r := NewTruncatingReader(iotest.OneByteReader(strings.NewReader(c.src)), 5, WithTruncationMarker([]byte("[...]")))
+		res, err := io.ReadAll(r)
+		if err != nil || string(res) != c.want {
+			t.Errorf("should %s but %s %v", c.want, res, err)
+		}
+	}
+
+	res, _ := io.ReadAll(NewTruncatingReader(strings.NewReader("hello world"), 5))
+	if string(res) != "hello" {
+		t.Errorf("should hello but %s", res)
+	}
+}
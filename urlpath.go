@@ -0,0 +1,99 @@
+package libio
+
+import (
+	"bytes"
+	"io"
+)
+
+// longest URL considered by NewURLPathSegmentReplacer, longer ones are left alone.
+const maxURLLen = 2048
+
+var httpScheme = []byte("http")
+
+type urlPathSegmentReplace struct {
+	segmentIndex int
+	newSegment   []byte
+}
+
+// NewURLPathSegmentReplacer returns a BytesReplacer that replaces the path segment
+// at segmentIndex, counted from 0, of the http:// and https:// URLs of the stream
+// with newSegment; "http://host/a/b" has the segments "a" and "b". URLs end at a
+// '?', a '#', a quote, an angle bracket or white space, and are only considered
+// up to maxURLLen bytes. Empty segments are not replaced.
+func NewURLPathSegmentReplacer(segmentIndex int, newSegment string) BytesReplacer {
+	if segmentIndex < 0 {
+		panic("libio.NewURLPathSegmentReplacer: negative segment index")
+	}
+	return &urlPathSegmentReplace{segmentIndex: segmentIndex, newSegment: []byte(newSegment)}
+}
+
+func (u *urlPathSegmentReplace) GetSizingHints() (int, int, float64) {
+	// matches go from the scheme to the end of the segment and are replaced by the
+	// same prefix followed by newSegment: the shortest is "http://h/" followed by
+	// one byte segments.
+	minLen := len("http://h/") + 2*u.segmentIndex + 1
+	ratio := float64(minLen) / float64(minLen-1+len(u.newSegment))
+	if ratio >= 1 {
+		ratio = -1
+	}
+	return maxURLLen, maxURLLen + len(u.newSegment), ratio
+}
+
+func (u *urlPathSegmentReplace) Index(buf []byte) (int, []byte, []byte) {
+	for off := 0; ; {
+		i := bytes.Index(buf[off:], httpScheme)
+		if i < 0 {
+			return -1, nil, nil
+		}
+		at := off + i
+		off = at + len(httpScheme)
+		url := buf[at:min(len(buf), at+maxURLLen)]
+		start, end, ok := u.segment(url)
+		if !ok || end == maxURLLen {
+			continue
+		}
+		replace := make([]byte, 0, start+len(u.newSegment))
+		return at, url[:end], append(append(replace, url[:start]...), u.newSegment...)
+	}
+}
+
+// segment returns the bounds of the segment to replace in the URL that url starts
+// with. A segment reaching the end of url is returned even though it may go on:
+// the reader does not replace it before it is known to be complete.
+func (u *urlPathSegmentReplace) segment(url []byte) (int, int, bool) {
+	i := len(httpScheme)
+	if i < len(url) && url[i] == 's' {
+		i++
+	}
+	if !bytes.HasPrefix(url[i:], []byte("://")) {
+		return 0, 0, false
+	}
+	i += len("://")
+	// skip the host.
+	for i < len(url) && url[i] != '/' && !isURLEnd(url[i]) {
+		i++
+	}
+	for n := 0; i < len(url) && url[i] == '/'; n++ {
+		start := i + 1
+		i = start
+		for i < len(url) && url[i] != '/' && !isURLEnd(url[i]) {
+			i++
+		}
+		if n == u.segmentIndex {
+			return start, i, i > start
+		}
+	}
+	return 0, 0, false
+}
+
+func (u *urlPathSegmentReplace) Replace(src io.Reader) io.Reader {
+	return (&StreamReplacingReader{}).ResetEx(src, u)
+}
+
+func isURLEnd(c byte) bool {
+	switch c {
+	case '?', '#', '"', '\'', '<', '>', ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
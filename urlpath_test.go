@@ -0,0 +1,30 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestURLPathSegmentReplacer(t *testing.T) {
+	src := `<a href="https://example.com/users/1234/profile?tab=1">` +
+		"http://example.com/users/99 http://example.com/ http://x/a//b https://x"
+	want := `<a href="https://example.com/users/ID/profile?tab=1">` +
+		"http://example.com/users/ID http://example.com/ http://x/a//b https://x"
+	r := (&StreamReplacingReader{}).ResetEx(iotest.OneByteReader(strings.NewReader(src)), NewURLPathSegmentReplacer(1, "ID"))
+	res, _ := io.ReadAll(r)
+	if string(res) != want {
+		t.Errorf("should %q but %q", want, res)
+	}
+}
+
+func TestURLPathSegmentReplacer_Expansion(t *testing.T) {
+	seg := strings.Repeat("s", 100)
+	src := strings.Repeat("http://h/a ", 1000)
+	r := (&StreamReplacingReader{}).ResetEx(strings.NewReader(src), NewURLPathSegmentReplacer(0, seg))
+	res, _ := io.ReadAll(r)
+	if want := strings.Repeat("http://h/"+seg+" ", 1000); string(res) != want {
+		t.Errorf("unexpected output of %d bytes, want %d", len(res), len(want))
+	}
+}
@@ -0,0 +1,43 @@
+package libio
+
+import (
+	"io"
+	"time"
+)
+
+// NewWatermarkReader returns a reader of src into which the watermark returned by
+// fn is injected every interval bytes of output; fn is given the output offset of
+// the watermark and the current time. Watermarks count as output, so the next one
+// is injected interval bytes after the previous one started, or at the next
+// multiple of interval if the previous one was longer.
+func NewWatermarkReader(src io.Reader, interval int64, fn func(offset int64, t time.Time) []byte) io.Reader {
+	if interval <= 0 {
+		panic("libio.NewWatermarkReader: interval must be positive")
+	}
+	var offset int64
+	next := interval
+	return newTransformReader(src, func(dst, p []byte) []byte {
+		for len(p) > 0 {
+			n := int(min64(next-offset, int64(len(p))))
+			dst = append(dst, p[:n]...)
+			p = p[n:]
+			offset += int64(n)
+			if offset == next {
+				wm := fn(offset, time.Now())
+				dst = append(dst, wm...)
+				offset += int64(len(wm))
+				for next <= offset {
+					next += interval
+				}
+			}
+		}
+		return dst
+	}, nil)
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,20 @@
+package libio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestWatermarkReader(t *testing.T) {
+	r := NewWatermarkReader(iotest.HalfReader(strings.NewReader("abcdefghijklmnopqrst")), 8, func(offset int64, _ time.Time) []byte {
+		return []byte(fmt.Sprintf("<%d>", offset))
+	})
+	res, err := io.ReadAll(r)
+	if want := "abcdefgh<8>ijklm<16>nopq<24>rst"; err != nil || string(res) != want {
+		t.Errorf("should %s but %s %v", want, res, err)
+	}
+}
@@ -0,0 +1,49 @@
+package libio
+
+import (
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NewWhitespaceNormalizer returns a reader of the UTF-8 text src in which every
+// run of whitespace, that is '\t', '\n', '\v', '\f', '\r', ' ' and the runes of the
+// Unicode category Zs such as U+00A0, is collapsed into a single ' '. Invalid UTF-8
+// is passed through as is.
+func NewWhitespaceNormalizer(src io.Reader) io.Reader {
+	inRun := false
+	var carry []byte // incomplete rune ending the previous read
+	return newTransformReader(src, func(dst, p []byte) []byte {
+		if len(carry) > 0 {
+			carry = append(carry, p...)
+			p = carry
+		}
+		for i := 0; i < len(p); {
+			b := p[i]
+			space, size := false, 1
+			if b < utf8.RuneSelf {
+				space = b == ' ' || (b >= '\t' && b <= '\r')
+			} else {
+				if !utf8.FullRune(p[i:]) {
+					carry = append(carry[:0], p[i:]...)
+					return dst
+				}
+				var r rune
+				r, size = utf8.DecodeRune(p[i:])
+				space = unicode.Is(unicode.Zs, r)
+			}
+			if !space {
+				dst = append(dst, p[i:i+size]...)
+				inRun = false
+			} else if !inRun {
+				dst = append(dst, ' ')
+				inRun = true
+			}
+			i += size
+		}
+		carry = carry[:0]
+		return dst
+	}, func(dst []byte) []byte {
+		return append(dst, carry...)
+	})
+}
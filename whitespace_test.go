@@ -0,0 +1,19 @@
+package libio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWhitespaceNormalizer(t *testing.T) {
+	src := "a \t\r\n b\u00a0\u3000c\u2003 d\xe3\x80"
+	want := "a b c d\xe3\x80"
+	for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.OneByteReader} {
+		res, err := io.ReadAll(NewWhitespaceNormalizer(wrap(strings.NewReader(src))))
+		if err != nil || string(res) != want {
+			t.Errorf("should %q but %q %v", want, res, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package libio
+
+import "io"
+
+type replacingWriter struct {
+	dst io.Writer
+	rw  *StreamReplacingReader
+	err error
+}
+
+// NewReplacingWriter returns a writer that replaces tokens with replacer before
+// forwarding the data to dst. Bytes that might be the beginning of a search token
+// are held back until the next Write, so Close must be called to flush them; it
+// does not close dst. Once writing to dst fails, the error is returned by every
+// later call.
+func NewReplacingWriter(dst io.Writer, replacer BytesReplacer) io.WriteCloser {
+	return &replacingWriter{dst: dst, rw: NewStreamReplacingReadWriter(replacer)}
+}
+
+func (w *replacingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.rw.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, w.flush()
+}
+
+func (w *replacingWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	_ = w.rw.Close()
+	return w.flush()
+}
+
+// flush forwards everything that has been replaced so far.
+func (w *replacingWriter) flush() error {
+	_, w.err = Copy(w.dst, w.rw)
+	return w.err
+}
@@ -0,0 +1,42 @@
+package libio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestReplacingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewReplacingWriter(&buf, NewReplacer("foo", "bar").(BytesReplacer))
+	for _, s := range []string{"a f", "oo b fo", "o f"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.String() != "a bar b bar" {
+		t.Errorf("partial token should be held back, got %s", buf.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "a bar b bar f" {
+		t.Errorf("should a bar b bar f but %s", buf.String())
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestReplacingWriter_Error(t *testing.T) {
+	w := NewReplacingWriter(errWriter{}, NewReplacer("foo", "bar").(BytesReplacer))
+	if _, err := w.Write([]byte("hello foo world")); err == nil {
+		t.Fatal("should fail")
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("error should be sticky")
+	}
+}